@@ -0,0 +1,76 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/rayzox/tickr-backend/models"
+)
+
+func (c *Client) ListEvents(ctx context.Context) ([]models.Event, error) {
+	var events []models.Event
+	err := c.do(ctx, "GET", "/calendar/events", nil, nil, &events)
+	return events, err
+}
+
+// EventsInRange lists the events from the start day to the end day, both included
+func (c *Client) EventsInRange(ctx context.Context, start, end time.Time) ([]models.Event, error) {
+	query := url.Values{"start": {start.Format("2006-01-02")}, "end": {end.Format("2006-01-02")}}
+	var events []models.Event
+	err := c.do(ctx, "GET", "/calendar/events/range", query, nil, &events)
+	return events, err
+}
+
+func (c *Client) GetEvent(ctx context.Context, id uint) (*models.Event, error) {
+	var event models.Event
+	if err := c.do(ctx, "GET", fmt.Sprintf("/calendar/events/%d", id), nil, nil, &event); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+func (c *Client) CreateEvent(ctx context.Context, event *models.Event) (*models.Event, error) {
+	var created models.Event
+	if err := c.do(ctx, "POST", "/calendar/events", nil, event, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+func (c *Client) UpdateEvent(ctx context.Context, id uint, event *models.Event) (*models.Event, error) {
+	var updated models.Event
+	if err := c.do(ctx, "PUT", fmt.Sprintf("/calendar/events/%d", id), nil, event, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+func (c *Client) PatchEvent(ctx context.Context, id uint, patch map[string]interface{}) (*models.Event, error) {
+	var updated models.Event
+	if err := c.do(ctx, "PATCH", fmt.Sprintf("/calendar/events/%d", id), nil, patch, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+func (c *Client) DeleteEvent(ctx context.Context, id uint) (*models.Message, error) {
+	var msg models.Message
+	if err := c.do(ctx, "DELETE", fmt.Sprintf("/calendar/events/%d", id), nil, nil, &msg); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+func (c *Client) BulkEvents(ctx context.Context, operations ...models.BulkOperation) (*models.BulkResponse, error) {
+	var resp models.BulkResponse
+	if err := c.do(ctx, "POST", "/calendar/events/bulk", nil, models.BulkRequest{Operations: operations}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *Client) EventHistory(ctx context.Context, id uint) ([]models.AuditLog, error) {
+	return c.history(ctx, fmt.Sprintf("/calendar/events/%d/history", id))
+}
@@ -0,0 +1,276 @@
+// Package client is a typed Go client for the tickr REST API. It covers every
+// route registered in routes/, under /api/v1, with requests and responses
+// using the structs in models. Calls take a context, failures the server
+// reports come back as *Error and transient ones are retried.
+//
+//	c := client.New("http://localhost:8080")
+//	task, err := c.CreateTask(ctx, &models.Task{Title: "Fix bug", Priority: "high"})
+//	if client.IsNotFound(err) { ... }
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rayzox/tickr-backend/models"
+)
+
+// apiPrefix is the version of the API the client speaks, BaseURL is the server root
+const apiPrefix = "/api/v1"
+
+// perPage is the page size lists are fetched with, the most the server allows
+const perPage = 500
+
+type Client struct {
+	BaseURL    string
+	Token      string // sent as a bearer token, for servers behind an auth proxy
+	User       string // sent as X-Tickr-User, audit logs and per-user settings are keyed on it
+	Name       string // sent as X-Tickr-Client, shows up in audit logs instead of the user agent
+	HTTPClient *http.Client
+
+	// Transient failures (network errors, 429, 502, 503 and 504) are retried
+	// this many times, waiting RetryDelay and then twice as long each time.
+	// POSTs carry an Idempotency-Key so a retry never creates anything twice.
+	Retries    int
+	RetryDelay time.Duration
+}
+
+func New(baseURL string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+		Retries:    3,
+		RetryDelay: 500 * time.Millisecond,
+	}
+}
+
+// Error is an error response of the server, Code and Message come from its error envelope
+type Error struct {
+	StatusCode int
+	Code       models.ErrorCode
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s (%d)", e.Message, e.StatusCode)
+}
+
+// StatusCode is the HTTP status of an *Error, 0 for any other error
+func StatusCode(err error) int {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	return 0
+}
+
+func IsNotFound(err error) bool { return StatusCode(err) == http.StatusNotFound }
+
+// IsConflict reports a 409, e.g. a focus session is already running or a sync edit lost
+func IsConflict(err error) bool { return StatusCode(err) == http.StatusConflict }
+
+// IsPreconditionFailed reports a 412, the row changed since the ETag given to IfMatch
+func IsPreconditionFailed(err error) bool { return StatusCode(err) == http.StatusPreconditionFailed }
+
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	body   []byte
+	header http.Header
+}
+
+// do sends body as JSON and decodes the JSON response into result, which can be nil
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, result interface{}) error {
+	req := &request{method: method, path: path, query: query, header: http.Header{}}
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		req.body = data
+		req.header.Set("Content-Type", "application/json")
+	}
+
+	return c.fetch(ctx, req, result)
+}
+
+// envelope is how every response comes, see models.Envelope
+type envelope struct {
+	Data    json.RawMessage `json:"data"`
+	Message string          `json:"message"`
+	Meta    *models.Meta    `json:"meta"`
+}
+
+// fetch sends the request and decodes the data of the response into result,
+// which can be nil. Lists are fetched page by page until complete.
+func (c *Client) fetch(ctx context.Context, req *request, result interface{}) error {
+	if req.method == http.MethodGet {
+		req.query = withQuery(req.query, "per_page", strconv.Itoa(perPage))
+	}
+
+	items := []json.RawMessage{}
+	for page := 1; ; page++ {
+		data, err := c.send(ctx, req)
+		if err != nil || result == nil {
+			return err
+		}
+		var env envelope
+		if err := json.Unmarshal(data, &env); err != nil {
+			return err
+		}
+		if env.Meta == nil {
+			return json.Unmarshal(withMessage(env.Data, env.Message), result)
+		}
+
+		var pageItems []json.RawMessage
+		if err := json.Unmarshal(env.Data, &pageItems); err != nil {
+			return err
+		}
+		items = append(items, pageItems...)
+		if page >= env.Meta.TotalPages {
+			all, err := json.Marshal(items)
+			if err != nil {
+				return err
+			}
+			return json.Unmarshal(all, result)
+		}
+		req.query = withQuery(req.query, "page", strconv.Itoa(page+1))
+	}
+}
+
+// withMessage puts the envelope's message back into the data, the result
+// types like models.Message have it as a field
+func withMessage(data json.RawMessage, message string) json.RawMessage {
+	if message == "" {
+		return data
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return data // not an object
+	}
+	if fields == nil {
+		fields = map[string]json.RawMessage{}
+	}
+	fields["message"], _ = json.Marshal(message)
+	merged, err := json.Marshal(fields)
+	if err != nil {
+		return data
+	}
+	return merged
+}
+
+func withQuery(query url.Values, key, value string) url.Values {
+	copied := url.Values{}
+	for k, v := range query {
+		copied[k] = v
+	}
+	copied.Set(key, value)
+	return copied
+}
+
+// send runs the request, retrying transient failures, and returns the body of a 2xx response
+func (c *Client) send(ctx context.Context, req *request) ([]byte, error) {
+	if req.method == http.MethodPost && req.header.Get("Idempotency-Key") == "" {
+		key := make([]byte, 16)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		req.header.Set("Idempotency-Key", hex.EncodeToString(key))
+	}
+
+	delay := c.RetryDelay
+	for attempt := 0; ; attempt++ {
+		data, retryAfter, err := c.attempt(ctx, req)
+		if err == nil || attempt >= c.Retries || !transient(err) || ctx.Err() != nil {
+			return data, err
+		}
+
+		wait := delay
+		if retryAfter > 0 {
+			wait = retryAfter
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		delay *= 2
+	}
+}
+
+// attempt sends the request once, returning the server's Retry-After along with any error
+func (c *Client) attempt(ctx context.Context, req *request) ([]byte, time.Duration, error) {
+	u := c.BaseURL + apiPrefix + req.path
+	if len(req.query) > 0 {
+		u += "?" + req.query.Encode()
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, u, bytes.NewReader(req.body))
+	if err != nil {
+		return nil, 0, err
+	}
+	for key, values := range req.header {
+		httpReq.Header[key] = values
+	}
+	c.authorize(httpReq)
+
+	resp, err := c.HTTPClient.Do(httpReq)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+	if resp.StatusCode < 300 {
+		return data, 0, nil
+	}
+
+	apiErr := &Error{StatusCode: resp.StatusCode, Message: http.StatusText(resp.StatusCode)}
+	var body models.ErrorEnvelope
+	if json.Unmarshal(data, &body) == nil && body.Error.Message != "" {
+		apiErr.Code, apiErr.Message = body.Error.Code, body.Error.Message
+	}
+	seconds, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+	return nil, time.Duration(seconds) * time.Second, apiErr
+}
+
+func (c *Client) authorize(req *http.Request) {
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	if c.User != "" {
+		req.Header.Set("X-Tickr-User", c.User)
+	}
+	if c.Name != "" {
+		req.Header.Set("X-Tickr-Client", c.Name)
+	}
+}
+
+// transient reports failures worth retrying: the server being briefly
+// unavailable or overloaded, or the connection failing. Cancellations aren't.
+func transient(err error) bool {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
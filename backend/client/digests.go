@@ -0,0 +1,64 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/rayzox/tickr-backend/models"
+)
+
+// DigestPreview is a rendered digest email
+type DigestPreview struct {
+	Kind    string `json:"kind"` // 'daily' or 'weekly'
+	Subject string `json:"subject"`
+	HTML    string `json:"html"`
+	Text    string `json:"text"`
+}
+
+// PreviewDigest renders the digest of the given kind as it would be sent now,
+// in timezone (empty for the server's)
+func (c *Client) PreviewDigest(ctx context.Context, kind, timezone string) (*DigestPreview, error) {
+	query := url.Values{"kind": {kind}, "format": {"json"}}
+	if timezone != "" {
+		query.Set("timezone", timezone)
+	}
+	var preview DigestPreview
+	if err := c.do(ctx, "GET", "/digests/preview", query, nil, &preview); err != nil {
+		return nil, err
+	}
+	return &preview, nil
+}
+
+// ListDigestSubscriptions lists the subscriptions of Client.User
+func (c *Client) ListDigestSubscriptions(ctx context.Context) ([]models.DigestSubscription, error) {
+	var subs []models.DigestSubscription
+	err := c.do(ctx, "GET", "/digests/subscriptions", nil, nil, &subs)
+	return subs, err
+}
+
+func (c *Client) CreateDigestSubscription(ctx context.Context, sub *models.DigestSubscriptionRequest) (*models.DigestSubscription, error) {
+	var created models.DigestSubscription
+	if err := c.do(ctx, "POST", "/digests/subscriptions", nil, sub, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+func (c *Client) UpdateDigestSubscription(ctx context.Context, id uint, sub *models.DigestSubscriptionRequest) (*models.DigestSubscription, error) {
+	var updated models.DigestSubscription
+	if err := c.do(ctx, "PUT", fmt.Sprintf("/digests/subscriptions/%d", id), nil, sub, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+func (c *Client) DeleteDigestSubscription(ctx context.Context, id uint) error {
+	return c.do(ctx, "DELETE", fmt.Sprintf("/digests/subscriptions/%d", id), nil, nil, nil)
+}
+
+// SendDigest emails the digest of the given kind to the subscription right away
+func (c *Client) SendDigest(ctx context.Context, id uint, kind string) error {
+	query := url.Values{"kind": {kind}}
+	return c.do(ctx, "POST", fmt.Sprintf("/digests/subscriptions/%d/send", id), query, nil, nil)
+}
@@ -0,0 +1,60 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rayzox/tickr-backend/models"
+)
+
+func (c *Client) ListHabits(ctx context.Context) ([]models.Habit, error) {
+	var habits []models.Habit
+	err := c.do(ctx, "GET", "/habits", nil, nil, &habits)
+	return habits, err
+}
+
+func (c *Client) GetHabit(ctx context.Context, id uint) (*models.Habit, error) {
+	var habit models.Habit
+	if err := c.do(ctx, "GET", fmt.Sprintf("/habits/%d", id), nil, nil, &habit); err != nil {
+		return nil, err
+	}
+	return &habit, nil
+}
+
+func (c *Client) CreateHabit(ctx context.Context, habit *models.Habit) (*models.Habit, error) {
+	var created models.Habit
+	if err := c.do(ctx, "POST", "/habits", nil, habit, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+func (c *Client) UpdateHabit(ctx context.Context, id uint, habit *models.Habit) (*models.Habit, error) {
+	var updated models.Habit
+	if err := c.do(ctx, "PUT", fmt.Sprintf("/habits/%d", id), nil, habit, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// PatchHabit applies a JSON merge patch like {"completed_today": true}
+func (c *Client) PatchHabit(ctx context.Context, id uint, patch map[string]interface{}) (*models.Habit, error) {
+	var updated models.Habit
+	if err := c.do(ctx, "PATCH", fmt.Sprintf("/habits/%d", id), nil, patch, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// DeleteHabit moves the habit and its calendar events to the trash
+func (c *Client) DeleteHabit(ctx context.Context, id uint) (*models.Message, error) {
+	var msg models.Message
+	if err := c.do(ctx, "DELETE", fmt.Sprintf("/habits/%d", id), nil, nil, &msg); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+func (c *Client) HabitHistory(ctx context.Context, id uint) ([]models.AuditLog, error) {
+	return c.history(ctx, fmt.Sprintf("/habits/%d/history", id))
+}
@@ -0,0 +1,44 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/rayzox/tickr-backend/models"
+)
+
+// SendInboundEmail turns a raw RFC 822 message into a task, the way a mail
+// provider's inbound webhook would. token is the server's inbound_token.
+func (c *Client) SendInboundEmail(ctx context.Context, message []byte, token string) (*models.Task, error) {
+	req := &request{method: "POST", path: "/inbound/email", body: message, header: http.Header{}}
+	req.header.Set("Content-Type", "message/rfc822")
+	if token != "" {
+		req.header.Set("X-Tickr-Inbound-Token", token)
+	}
+
+	var task models.Task
+	if err := c.fetch(ctx, req, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// ListInboundSenders lists the addresses Client.User allowed to create tasks by email
+func (c *Client) ListInboundSenders(ctx context.Context) ([]models.InboundSender, error) {
+	var senders []models.InboundSender
+	err := c.do(ctx, "GET", "/inbound/senders", nil, nil, &senders)
+	return senders, err
+}
+
+func (c *Client) AddInboundSender(ctx context.Context, address string) (*models.InboundSender, error) {
+	var sender models.InboundSender
+	if err := c.do(ctx, "POST", "/inbound/senders", nil, models.InboundSenderRequest{Address: address}, &sender); err != nil {
+		return nil, err
+	}
+	return &sender, nil
+}
+
+func (c *Client) RemoveInboundSender(ctx context.Context, id uint) error {
+	return c.do(ctx, "DELETE", fmt.Sprintf("/inbound/senders/%d", id), nil, nil, nil)
+}
@@ -0,0 +1,115 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/rayzox/tickr-backend/models"
+)
+
+func (c *Client) ListReminders(ctx context.Context) ([]models.Reminder, error) {
+	var reminders []models.Reminder
+	err := c.do(ctx, "GET", "/reminders", nil, nil, &reminders)
+	return reminders, err
+}
+
+func (c *Client) CreateReminder(ctx context.Context, reminder *models.Reminder) (*models.Reminder, error) {
+	var created models.Reminder
+	if err := c.do(ctx, "POST", "/reminders", nil, reminder, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+func (c *Client) DeleteReminder(ctx context.Context, id uint) error {
+	return c.do(ctx, "DELETE", fmt.Sprintf("/reminders/%d", id), nil, nil, nil)
+}
+
+// ListNotifications returns the delivered notifications, newest first
+func (c *Client) ListNotifications(ctx context.Context, unreadOnly bool) (*models.NotificationInbox, error) {
+	var query url.Values
+	if unreadOnly {
+		query = url.Values{"unread": {"true"}}
+	}
+	var inbox models.NotificationInbox
+	if err := c.do(ctx, "GET", "/notifications", query, nil, &inbox); err != nil {
+		return nil, err
+	}
+	return &inbox, nil
+}
+
+func (c *Client) MarkNotificationRead(ctx context.Context, id uint) (*models.Notification, error) {
+	var notification models.Notification
+	if err := c.do(ctx, "POST", fmt.Sprintf("/notifications/%d/read", id), nil, nil, &notification); err != nil {
+		return nil, err
+	}
+	return &notification, nil
+}
+
+// MarkAllNotificationsRead returns how many notifications were unread
+func (c *Client) MarkAllNotificationsRead(ctx context.Context) (int64, error) {
+	var resp models.MarkReadResult
+	err := c.do(ctx, "POST", "/notifications/read", nil, nil, &resp)
+	return resp.Updated, err
+}
+
+// SnoozeNotification hides the notification for a few minutes, 0 means 10
+func (c *Client) SnoozeNotification(ctx context.Context, id uint, minutes int) (*models.Notification, error) {
+	var notification models.Notification
+	if err := c.do(ctx, "POST", fmt.Sprintf("/notifications/%d/snooze", id), nil, models.SnoozeRequest{Minutes: minutes}, &notification); err != nil {
+		return nil, err
+	}
+	return &notification, nil
+}
+
+// StreamNotifications calls fn with every notification as it's delivered,
+// until ctx is done or the server closes the stream. It isn't retried, call
+// it again to reconnect.
+func (c *Client) StreamNotifications(ctx context.Context, fn func(models.Notification)) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.BaseURL+apiPrefix+"/notifications/stream", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	c.authorize(req)
+
+	// The stream stays open for as long as the caller wants
+	httpClient := *c.HTTPClient
+	httpClient.Timeout = 0
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return &Error{StatusCode: resp.StatusCode, Message: http.StatusText(resp.StatusCode)}
+	}
+
+	event := ""
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(line[len("event:"):])
+		case strings.HasPrefix(line, "data:") && event == "notification":
+			var notification models.Notification
+			if err := json.Unmarshal([]byte(strings.TrimSpace(line[len("data:"):])), &notification); err != nil {
+				return err
+			}
+			fn(notification)
+		case line == "":
+			event = ""
+		}
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,44 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rayzox/tickr-backend/models"
+)
+
+// CreatePomodoroSession records a finished pomodoro, CompletedAt defaults to now
+func (c *Client) CreatePomodoroSession(ctx context.Context, session *models.PomodoroSession) (*models.PomodoroSession, error) {
+	var created models.PomodoroSession
+	if err := c.do(ctx, "POST", "/pomodoro/sessions", nil, session, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+func (c *Client) ListPomodoroSessions(ctx context.Context) ([]models.PomodoroSession, error) {
+	var sessions []models.PomodoroSession
+	err := c.do(ctx, "GET", "/pomodoro/sessions", nil, nil, &sessions)
+	return sessions, err
+}
+
+func (c *Client) PomodoroStats(ctx context.Context) (*models.PomodoroStats, error) {
+	var stats models.PomodoroStats
+	if err := c.do(ctx, "GET", "/pomodoro/stats", nil, nil, &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// ClearPomodoroSessions moves every session to the trash
+func (c *Client) ClearPomodoroSessions(ctx context.Context) (*models.Message, error) {
+	var msg models.Message
+	if err := c.do(ctx, "DELETE", "/pomodoro/sessions", nil, nil, &msg); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+func (c *Client) PomodoroHistory(ctx context.Context, id uint) ([]models.AuditLog, error) {
+	return c.history(ctx, fmt.Sprintf("/pomodoro/sessions/%d/history", id))
+}
@@ -0,0 +1,71 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rayzox/tickr-backend/models"
+)
+
+func (c *Client) Dashboard(ctx context.Context) (*models.Dashboard, error) {
+	var dashboard models.Dashboard
+	if err := c.do(ctx, "GET", "/productivity/dashboard", nil, nil, &dashboard); err != nil {
+		return nil, err
+	}
+	return &dashboard, nil
+}
+
+func (c *Client) WeeklyAnalytics(ctx context.Context) (*models.Weekly, error) {
+	var weekly models.Weekly
+	if err := c.do(ctx, "GET", "/productivity/analytics/weekly", nil, nil, &weekly); err != nil {
+		return nil, err
+	}
+	return &weekly, nil
+}
+
+// StartFocus starts a focus session, IsConflict(err) when one is already running
+func (c *Client) StartFocus(ctx context.Context, req models.FocusStartRequest) (*models.FocusSession, error) {
+	var session models.FocusSession
+	if err := c.do(ctx, "POST", "/productivity/focus/start", nil, req, &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (c *Client) CompleteFocus(ctx context.Context, id uint, req models.FocusCompleteRequest) (*models.FocusSession, error) {
+	var session models.FocusSession
+	if err := c.do(ctx, "PUT", fmt.Sprintf("/productivity/focus/%d/complete", id), nil, req, &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// ActiveFocus returns the running focus session, nil when there's none
+func (c *Client) ActiveFocus(ctx context.Context) (*models.FocusSession, error) {
+	var session *models.FocusSession
+	if err := c.do(ctx, "GET", "/productivity/focus/active", nil, nil, &session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+func (c *Client) FocusHistory(ctx context.Context, id uint) ([]models.AuditLog, error) {
+	return c.history(ctx, fmt.Sprintf("/productivity/focus/%d/history", id))
+}
+
+// ScheduleTask puts the task on the calendar, moving its event if it already has one
+func (c *Client) ScheduleTask(ctx context.Context, req models.ScheduleTaskRequest) (*models.Event, error) {
+	var event models.Event
+	if err := c.do(ctx, "POST", "/productivity/schedule/task", nil, req, &event); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+func (c *Client) ScheduleHabit(ctx context.Context, req models.ScheduleHabitRequest) (*models.ScheduleHabitResult, error) {
+	var result models.ScheduleHabitResult
+	if err := c.do(ctx, "POST", "/productivity/schedule/habit", nil, req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
@@ -0,0 +1,33 @@
+package client
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+
+	"github.com/rayzox/tickr-backend/models"
+)
+
+// Pull returns the records changed after the since cursor, limit 0 uses the
+// server's default. Keep pulling from the returned cursor while HasMore.
+func (c *Client) Pull(ctx context.Context, since uint, limit int) (*models.SyncPullResponse, error) {
+	query := url.Values{"since": {strconv.FormatUint(uint64(since), 10)}}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	var resp models.SyncPullResponse
+	if err := c.do(ctx, "GET", "/sync", query, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Push applies offline edits. Edits that conflict come back with status
+// 'conflict' and the server's version, they don't fail the call.
+func (c *Client) Push(ctx context.Context, edits ...models.SyncEdit) (*models.SyncPushResponse, error) {
+	var resp models.SyncPushResponse
+	if err := c.do(ctx, "POST", "/sync", nil, models.SyncPushRequest{Changes: edits}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
@@ -0,0 +1,80 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/rayzox/tickr-backend/models"
+)
+
+func (c *Client) ListTasks(ctx context.Context) ([]models.Task, error) {
+	var tasks []models.Task
+	err := c.do(ctx, "GET", "/tasks", nil, nil, &tasks)
+	return tasks, err
+}
+
+func (c *Client) GetTask(ctx context.Context, id uint) (*models.Task, error) {
+	var task models.Task
+	if err := c.do(ctx, "GET", fmt.Sprintf("/tasks/%d", id), nil, nil, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+func (c *Client) CreateTask(ctx context.Context, task *models.Task) (*models.Task, error) {
+	var created models.Task
+	if err := c.do(ctx, "POST", "/tasks", nil, task, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// UpdateTask replaces the whole task, PatchTask changes only some fields
+func (c *Client) UpdateTask(ctx context.Context, id uint, task *models.Task) (*models.Task, error) {
+	var updated models.Task
+	if err := c.do(ctx, "PUT", fmt.Sprintf("/tasks/%d", id), nil, task, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// PatchTask applies a JSON merge patch like {"completed": true}
+func (c *Client) PatchTask(ctx context.Context, id uint, patch map[string]interface{}) (*models.Task, error) {
+	var updated models.Task
+	if err := c.do(ctx, "PATCH", fmt.Sprintf("/tasks/%d", id), nil, patch, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// DeleteTask moves the task to the trash, withEvents trashes its calendar events too
+func (c *Client) DeleteTask(ctx context.Context, id uint, withEvents bool) (*models.Message, error) {
+	var query url.Values
+	if withEvents {
+		query = url.Values{"with_events": {"true"}}
+	}
+	var msg models.Message
+	if err := c.do(ctx, "DELETE", fmt.Sprintf("/tasks/%d", id), query, nil, &msg); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+func (c *Client) BulkTasks(ctx context.Context, operations ...models.BulkOperation) (*models.BulkResponse, error) {
+	var resp models.BulkResponse
+	if err := c.do(ctx, "POST", "/tasks/bulk", nil, models.BulkRequest{Operations: operations}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *Client) TaskHistory(ctx context.Context, id uint) ([]models.AuditLog, error) {
+	return c.history(ctx, fmt.Sprintf("/tasks/%d/history", id))
+}
+
+func (c *Client) history(ctx context.Context, path string) ([]models.AuditLog, error) {
+	var history []models.AuditLog
+	err := c.do(ctx, "GET", path, nil, nil, &history)
+	return history, err
+}
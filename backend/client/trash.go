@@ -0,0 +1,46 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/rayzox/tickr-backend/models"
+)
+
+// ListTrash lists soft-deleted records, of one entity type ('task', 'habit',
+// 'event', 'pomodoro', 'focus') or all of them when kind is empty
+func (c *Client) ListTrash(ctx context.Context, kind string) ([]models.TrashItem, error) {
+	var query url.Values
+	if kind != "" {
+		query = url.Values{"type": {kind}}
+	}
+	var items []models.TrashItem
+	err := c.do(ctx, "GET", "/trash", query, nil, &items)
+	return items, err
+}
+
+func (c *Client) RestoreTrashItem(ctx context.Context, kind string, id uint) error {
+	return c.do(ctx, "POST", fmt.Sprintf("/trash/%s/%d/restore", url.PathEscape(kind), id), nil, nil, nil)
+}
+
+// PurgeTrashItem deletes a trashed record for good
+func (c *Client) PurgeTrashItem(ctx context.Context, kind string, id uint) error {
+	return c.do(ctx, "DELETE", fmt.Sprintf("/trash/%s/%d", url.PathEscape(kind), id), nil, nil, nil)
+}
+
+// EmptyTrash purges everything in the trash and returns how many records went
+func (c *Client) EmptyTrash(ctx context.Context) (int64, error) {
+	var resp models.PurgeResult
+	err := c.do(ctx, "DELETE", "/trash", nil, nil, &resp)
+	return resp.Purged, err
+}
+
+// Undo reverts the operation that returned the token, see models.Message
+func (c *Client) Undo(ctx context.Context, token string) (*models.UndoResult, error) {
+	var result models.UndoResult
+	if err := c.do(ctx, "POST", "/undo/"+url.PathEscape(token), nil, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
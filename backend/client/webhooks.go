@@ -0,0 +1,61 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rayzox/tickr-backend/models"
+)
+
+func (c *Client) ListWebhooks(ctx context.Context) (*models.WebhookList, error) {
+	var list models.WebhookList
+	if err := c.do(ctx, "GET", "/webhooks", nil, nil, &list); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+func (c *Client) GetWebhook(ctx context.Context, id uint) (*models.Webhook, error) {
+	var hook models.Webhook
+	if err := c.do(ctx, "GET", fmt.Sprintf("/webhooks/%d", id), nil, nil, &hook); err != nil {
+		return nil, err
+	}
+	return &hook, nil
+}
+
+// CreateWebhook registers a webhook. The secret is in the response, and only there.
+func (c *Client) CreateWebhook(ctx context.Context, hook *models.WebhookRequest) (*models.WebhookCreated, error) {
+	var created models.WebhookCreated
+	if err := c.do(ctx, "POST", "/webhooks", nil, hook, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+func (c *Client) UpdateWebhook(ctx context.Context, id uint, hook *models.WebhookRequest) (*models.Webhook, error) {
+	var updated models.Webhook
+	if err := c.do(ctx, "PUT", fmt.Sprintf("/webhooks/%d", id), nil, hook, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+func (c *Client) DeleteWebhook(ctx context.Context, id uint) error {
+	return c.do(ctx, "DELETE", fmt.Sprintf("/webhooks/%d", id), nil, nil, nil)
+}
+
+// WebhookDeliveries is the delivery log of the webhook, newest first
+func (c *Client) WebhookDeliveries(ctx context.Context, id uint) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := c.do(ctx, "GET", fmt.Sprintf("/webhooks/%d/deliveries", id), nil, nil, &deliveries)
+	return deliveries, err
+}
+
+// TestWebhook sends a ping event right away and returns the delivery
+func (c *Client) TestWebhook(ctx context.Context, id uint) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	if err := c.do(ctx, "POST", fmt.Sprintf("/webhooks/%d/test", id), nil, nil, &delivery); err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Words completed after a command
+var subcommands = map[string][]string{
+	"pomo":       {"start", "stop", "status", "stats"},
+	"habit":      {"ls", "check", "uncheck"},
+	"config":     {"set"},
+	"completion": {"bash", "zsh", "fish"},
+}
+
+const bashCompletion = `_tickr() {
+	local cur=${COMP_WORDS[COMP_CWORD]}
+	if [ "$COMP_CWORD" -eq 1 ]; then
+		COMPREPLY=($(compgen -W "%[1]s" -- "$cur"))
+		return
+	fi
+	case ${COMP_WORDS[1]} in
+%[2]s	esac
+}
+complete -F _tickr tickr
+`
+
+const zshCompletion = `#compdef tickr
+_tickr() {
+	if (( CURRENT == 2 )); then
+		compadd %[1]s
+		return
+	fi
+	case $words[2] in
+%[2]s	esac
+}
+compdef _tickr tickr
+`
+
+func runCompletion(a *app, args []string) error {
+	if len(args) != 1 {
+		return usageError("completion")
+	}
+
+	names := make([]string, 0, len(commands))
+	for _, cmd := range commands {
+		names = append(names, cmd.name)
+	}
+
+	var cases strings.Builder
+	switch args[0] {
+	case "bash":
+		for _, name := range names {
+			if words, ok := subcommands[name]; ok {
+				fmt.Fprintf(&cases, "\t%s) [ \"$COMP_CWORD\" -eq 2 ] && COMPREPLY=($(compgen -W \"%s\" -- \"$cur\")) ;;\n", name, strings.Join(words, " "))
+			}
+		}
+		fmt.Fprintf(a.out, bashCompletion, strings.Join(names, " "), cases.String())
+	case "zsh":
+		for _, name := range names {
+			if words, ok := subcommands[name]; ok {
+				fmt.Fprintf(&cases, "\t%s) (( CURRENT == 3 )) && compadd %s ;;\n", name, strings.Join(words, " "))
+			}
+		}
+		fmt.Fprintf(a.out, zshCompletion, strings.Join(names, " "), cases.String())
+	case "fish":
+		fmt.Fprintln(a.out, "complete -c tickr -f")
+		for _, cmd := range commands {
+			fmt.Fprintf(a.out, "complete -c tickr -n __fish_use_subcommand -a %s -d %q\n", cmd.name, cmd.summary)
+		}
+		for _, name := range names {
+			if words, ok := subcommands[name]; ok {
+				fmt.Fprintf(a.out, "complete -c tickr -n '__fish_seen_subcommand_from %s' -a %q\n", name, strings.Join(words, " "))
+			}
+		}
+	default:
+		return usageError("completion")
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rayzox/tickr-backend/client"
+)
+
+type config struct {
+	Server string `json:"server,omitempty"`
+	Token  string `json:"token,omitempty"`  // sent as a bearer token, for servers behind an auth proxy
+	User   string `json:"user,omitempty"`   // sent as X-Tickr-User, the server keys audit logs and subscriptions on it
+	Output string `json:"output,omitempty"` // 'table' or 'json'
+}
+
+var configKeys = []string{"server", "token", "user", "output"}
+
+type app struct {
+	ctx        context.Context
+	cfg        config
+	configPath string
+	out        io.Writer
+}
+
+// newApp loads the settings, lowest precedence first: defaults, the config
+// file, the environment. Flags are applied on top when each command parses them.
+func newApp(ctx context.Context, args []string, out io.Writer) (*app, error) {
+	a := &app{
+		ctx:        ctx,
+		cfg:        config{Server: "http://localhost:8080", Output: "table"},
+		configPath: configPath(args),
+		out:        out,
+	}
+
+	data, err := os.ReadFile(a.configPath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil {
+		var file config
+		if err := json.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("reading %s: %w", a.configPath, err)
+		}
+		for _, key := range configKeys {
+			if v := *file.field(key); v != "" {
+				*a.cfg.field(key) = v
+			}
+		}
+	}
+
+	for key, field := range map[string]*string{
+		"TICKR_SERVER": &a.cfg.Server,
+		"TICKR_TOKEN":  &a.cfg.Token,
+		"TICKR_USER":   &a.cfg.User,
+		"TICKR_OUTPUT": &a.cfg.Output,
+	} {
+		if v := os.Getenv(key); v != "" {
+			*field = v
+		}
+	}
+	return a, nil
+}
+
+// api is a client for the settings as they are once flags are parsed
+func (a *app) api() *client.Client {
+	c := client.New(a.cfg.Server)
+	c.Token = a.cfg.Token
+	c.User = a.cfg.User
+	c.Name = "tickr-cli"
+	return c
+}
+
+// configPath is --config if given anywhere in args, TICKR_CONFIG or the
+// user's config dir. It's needed before flags can be parsed.
+func configPath(args []string) string {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		for _, name := range []string{"-config", "--config"} {
+			if arg == name && i+1 < len(args) {
+				return args[i+1]
+			}
+			if strings.HasPrefix(arg, name+"=") {
+				return arg[len(name)+1:]
+			}
+		}
+	}
+	if path := os.Getenv("TICKR_CONFIG"); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "tickr.json"
+	}
+	return filepath.Join(dir, "tickr", "config.json")
+}
+
+// flags returns a flag set for the command with the global flags on it
+func (a *app) flags(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.StringVar(&a.cfg.Server, "server", a.cfg.Server, "server URL")
+	fs.StringVar(&a.cfg.Token, "token", a.cfg.Token, "API token")
+	fs.StringVar(&a.cfg.User, "user", a.cfg.User, "user name sent to the server")
+	fs.StringVar(&a.cfg.Output, "output", a.cfg.Output, "output format, table or json")
+	fs.StringVar(&a.configPath, "config", a.configPath, "config file")
+	fs.BoolFunc("json", "shorthand for --output json", func(string) error {
+		a.cfg.Output = "json"
+		return nil
+	})
+	return fs
+}
+
+// field returns the setting named key, nil for unknown keys
+func (c *config) field(key string) *string {
+	switch key {
+	case "server":
+		return &c.Server
+	case "token":
+		return &c.Token
+	case "user":
+		return &c.User
+	case "output":
+		return &c.Output
+	}
+	return nil
+}
+
+func runConfig(a *app, args []string) error {
+	args, err := parseFlags(a.flags("config"), args)
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		fmt.Fprintf(a.out, "file    %s\n", a.configPath)
+		for _, key := range configKeys {
+			value := *a.cfg.field(key)
+			if key == "token" && value != "" {
+				value = "(set)"
+			}
+			fmt.Fprintf(a.out, "%-7s %s\n", key, value)
+		}
+		return nil
+	}
+
+	if len(args) != 3 || args[0] != "set" {
+		return usageError("config")
+	}
+	if a.cfg.field(args[1]) == nil {
+		return fmt.Errorf("unknown key %q, one of %s", args[1], strings.Join(configKeys, ", "))
+	}
+
+	// Only the file's own settings go back in it, not the environment's or the flags'
+	var file config
+	if data, err := os.ReadFile(a.configPath); err == nil {
+		if err := json.Unmarshal(data, &file); err != nil {
+			return fmt.Errorf("reading %s: %w", a.configPath, err)
+		}
+	}
+	*file.field(args[1]) = args[2]
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(a.configPath), 0o700); err != nil {
+		return err
+	}
+	// The token is a secret
+	return os.WriteFile(a.configPath, append(data, '\n'), 0o600)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/rayzox/tickr-backend/client"
+	"github.com/rayzox/tickr-backend/models"
+)
+
+func runHabit(a *app, args []string) error {
+	args, err := parseFlags(a.flags("habit"), args)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return usageError("habit")
+	}
+
+	switch args[0] {
+	case "ls":
+		habits, err := a.api().ListHabits(a.ctx)
+		if err != nil {
+			return err
+		}
+		return a.print(habits, func(w io.Writer) {
+			printHabits(w, habits)
+		})
+
+	case "check", "uncheck":
+		ids, err := parseIDs(args[1:])
+		if err != nil || len(ids) == 0 {
+			return usageError("habit")
+		}
+		api := a.api()
+		habits := []*models.Habit{}
+		for _, id := range ids {
+			habit, err := checkHabit(a.ctx, api, id, args[0] == "check")
+			if err != nil {
+				return fmt.Errorf("habit %d: %w", id, err)
+			}
+			habits = append(habits, habit)
+		}
+		return a.print(habits, func(w io.Writer) {
+			for _, habit := range habits {
+				fmt.Fprintf(w, "[%s] %d %s, %d day streak\n", check(habit.CompletedToday), habit.ID, habit.Name, habit.Streak)
+			}
+		})
+	}
+	return usageError("habit")
+}
+
+// checkHabit marks a habit done or not done today, moving the streak along
+// the same way the dashboard does. Checking twice on a day is a no-op.
+func checkHabit(ctx context.Context, api *client.Client, id uint, done bool) (*models.Habit, error) {
+	habit, err := api.GetHabit(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if habit.CompletedToday == done {
+		return habit, nil
+	}
+
+	patch := map[string]interface{}{"completed_today": done}
+	if done {
+		patch["streak"] = habit.Streak + 1
+		patch["last_completed_at"] = time.Now().Format(time.RFC3339)
+	} else if habit.Streak > 0 {
+		patch["streak"] = habit.Streak - 1
+	}
+
+	return api.PatchHabit(ctx, id, patch)
+}
+
+func printHabits(w io.Writer, habits []models.Habit) {
+	fmt.Fprintln(w, "ID\tTODAY\tHABIT\tFREQUENCY\tSTREAK")
+	for _, habit := range habits {
+		fmt.Fprintf(w, "%d\t[%s]\t%s\t%s\t%d\n", habit.ID, check(habit.CompletedToday), habit.Name, orDash(habit.Frequency), habit.Streak)
+	}
+}
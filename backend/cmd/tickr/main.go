@@ -0,0 +1,143 @@
+// Command tickr manages tasks, habits and focus sessions of a tickr server
+// from the terminal:
+//
+//	tickr add "Fix bug" --due tomorrow --priority high
+//	tickr ls
+//	tickr done 42
+//	tickr pomo start 42
+//	tickr habit check 3
+//	tickr today
+//
+// Run "tickr help" for every command and flag.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+)
+
+// command is one "tickr <name>" subcommand. Commands with subcommands of
+// their own (pomo, habit) dispatch on args themselves.
+type command struct {
+	name    string
+	args    string
+	summary string
+	run     func(a *app, args []string) error
+}
+
+var commands []command
+
+// init breaks the cycle between the table and help, which lists it
+func init() {
+	commands = []command{
+		{"add", "<title> [--due day] [--priority p] [--tags t] [--description d] [--estimate n]", "Create a task", runAdd},
+		{"ls", "[--all] [--due day] [--tag t]", "List open tasks, --all includes completed ones", runList},
+		{"show", "<id>", "Show one task", runShow},
+		{"done", "<id>...", "Mark tasks as completed", runDone},
+		{"reopen", "<id>...", "Mark tasks as not completed", runReopen},
+		{"rm", "<id>... [--with-events]", "Move tasks to the trash", runRemove},
+		{"today", "", "Today's tasks, habits, events and focus session", runToday},
+		{"events", "[--from day] [--to day]", "Calendar events, the next 7 days by default", runEvents},
+		{"pomo", "start <task-id> [--minutes n] | stop [--notes n] [--abandon] | status | stats", "Focus sessions and pomodoros", runPomo},
+		{"habit", "ls | check <id>... | uncheck <id>...", "List and check off habits", runHabit},
+		{"config", "[set <key> <value>]", "Show or change the config file", runConfig},
+		{"completion", "bash|zsh|fish", "Print a shell completion script", runCompletion},
+		{"help", "[command]", "Show help", runHelp},
+	}
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := run(ctx, os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "tickr:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, out io.Writer) error {
+	a, err := newApp(ctx, args, out)
+	if err != nil {
+		return err
+	}
+
+	// Global flags can come before the command too
+	fs := a.flags("tickr")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	args = fs.Args()
+	if len(args) == 0 {
+		return runHelp(a, nil)
+	}
+
+	cmd, ok := findCommand(args[0])
+	if !ok {
+		return fmt.Errorf("unknown command %q, see \"tickr help\"", args[0])
+	}
+	return cmd.run(a, args[1:])
+}
+
+func findCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
+
+// parseFlags parses flags wherever they are among the positional arguments,
+// which the flag package stops at, and returns the positional ones
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		if args[0] == "--" {
+			return append(positional, args[1:]...), nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func runHelp(a *app, args []string) error {
+	if len(args) > 0 {
+		cmd, ok := findCommand(args[0])
+		if !ok {
+			return fmt.Errorf("unknown command %q", args[0])
+		}
+		fmt.Fprintf(a.out, "Usage: tickr %s %s\n\n%s\n", cmd.name, cmd.args, cmd.summary)
+		return nil
+	}
+
+	fmt.Fprintln(a.out, "Usage: tickr [flags] <command> [args]")
+	fmt.Fprintln(a.out, "\nCommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(a.out, "  %-11s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(a.out, "\nFlags, accepted anywhere on the line:")
+	fs := a.flags("tickr")
+	fs.SetOutput(a.out)
+	fs.PrintDefaults()
+	fmt.Fprintln(a.out, "\nDays (--due, --from, --to) are today, tomorrow, next-week, a weekday like fri, 3d, 2w or 2006-01-02.")
+	fmt.Fprintf(a.out, "Settings are read from %s, then TICKR_SERVER, TICKR_TOKEN, TICKR_USER and TICKR_OUTPUT, then flags.\n", a.configPath)
+	return nil
+}
+
+// usageError reports wrong arguments along with the command's usage line
+func usageError(name string) error {
+	cmd, _ := findCommand(name)
+	return fmt.Errorf("usage: tickr %s %s", cmd.name, cmd.args)
+}
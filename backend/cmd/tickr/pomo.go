@@ -0,0 +1,117 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/rayzox/tickr-backend/models"
+)
+
+var errNoFocus = errors.New("no focus session is running")
+
+func runPomo(a *app, args []string) error {
+	fs := a.flags("pomo")
+	minutes := fs.Int("minutes", 0, "planned length of the session, the server's default when 0")
+	notes := fs.String("notes", "", "notes for the finished session")
+	abandon := fs.Bool("abandon", false, "stop without counting a pomodoro")
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return usageError("pomo")
+	}
+
+	switch args[0] {
+	case "start":
+		ids, err := parseIDs(args[1:])
+		if err != nil || len(ids) != 1 {
+			return usageError("pomo")
+		}
+		session, err := a.api().StartFocus(a.ctx, models.FocusStartRequest{TaskID: ids[0], PlannedDuration: *minutes})
+		if err != nil {
+			return err
+		}
+		return a.print(session, func(w io.Writer) {
+			fmt.Fprintf(w, "Focusing on %q for %d minutes, until %s\n",
+				session.Task.Title, session.PlannedDuration, session.StartTime.Add(time.Duration(session.PlannedDuration)*time.Minute).Local().Format("15:04"))
+		})
+
+	case "stop":
+		session, err := activeFocus(a)
+		if err != nil {
+			return err
+		}
+		return stopFocus(a, session, *notes, !*abandon)
+
+	case "status":
+		session, err := activeFocus(a)
+		if errors.Is(err, errNoFocus) {
+			return a.print(map[string]interface{}{"active_session": nil}, func(w io.Writer) {
+				fmt.Fprintln(w, "No focus session running")
+			})
+		}
+		if err != nil {
+			return err
+		}
+		return a.print(session, func(w io.Writer) {
+			elapsed := time.Since(session.StartTime).Round(time.Minute)
+			fmt.Fprintf(w, "Focusing on %q (task %d), %s of %d minutes\n",
+				session.Task.Title, session.TaskID, elapsed, session.PlannedDuration)
+		})
+
+	case "stats":
+		stats, err := a.api().PomodoroStats(a.ctx)
+		if err != nil {
+			return err
+		}
+		return a.print(stats, func(w io.Writer) {
+			fmt.Fprintf(w, "Today\t%d pomodoros\n", stats.TodaySessions)
+			fmt.Fprintf(w, "All time\t%d pomodoros, %d minutes\n", stats.CompletedSessions, stats.TotalMinutes)
+		})
+	}
+	return usageError("pomo")
+}
+
+func activeFocus(a *app) (*models.FocusSession, error) {
+	session, err := a.api().ActiveFocus(a.ctx)
+	if err == nil && session == nil {
+		return nil, errNoFocus
+	}
+	return session, err
+}
+
+// stopFocus ends the session. A completed one is also recorded as a work
+// pomodoro, which counts towards the task's completed pomodoros.
+func stopFocus(a *app, session *models.FocusSession, notes string, completed bool) error {
+	api := a.api()
+	request := models.FocusCompleteRequest{Notes: notes, Completed: completed}
+
+	if completed {
+		pomodoro, err := api.CreatePomodoroSession(a.ctx, &models.PomodoroSession{
+			Phase:      "work",
+			Duration:   int(time.Since(session.StartTime).Minutes()),
+			TaskID:     &session.TaskID,
+			Notes:      notes,
+			Productive: true,
+		})
+		if err != nil {
+			return err
+		}
+		request.PomodoroID = &pomodoro.ID
+	}
+
+	stopped, err := api.CompleteFocus(a.ctx, session.ID, request)
+	if err != nil {
+		return err
+	}
+	return a.print(stopped, func(w io.Writer) {
+		verb := "Finished"
+		if !completed {
+			verb = "Abandoned"
+		}
+		fmt.Fprintf(w, "%s %q after %d minutes\n", verb, session.Task.Title, stopped.ActualDuration)
+	})
+}
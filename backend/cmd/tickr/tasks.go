@@ -0,0 +1,226 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rayzox/tickr-backend/dates"
+	"github.com/rayzox/tickr-backend/models"
+)
+
+func runAdd(a *app, args []string) error {
+	fs := a.flags("add")
+	due := fs.String("due", "", "due day")
+	priority := fs.String("priority", "", "low, medium or high")
+	tags := fs.String("tags", "", "comma separated tags")
+	description := fs.String("description", "", "description")
+	estimate := fs.Int("estimate", 0, "estimated pomodoros")
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return usageError("add")
+	}
+
+	task := &models.Task{
+		Title:              strings.Join(args, " "),
+		Priority:           *priority,
+		Tags:               *tags,
+		Description:        *description,
+		EstimatedPomodoros: *estimate,
+	}
+	if *due != "" {
+		day, err := dates.ParseDay(*due, time.Now())
+		if err != nil {
+			return err
+		}
+		// Same default time the server gives date-only due dates
+		task.DueDate = day.Add(9 * time.Hour)
+	}
+
+	task, err = a.api().CreateTask(a.ctx, task)
+	if err != nil {
+		return err
+	}
+	return a.print(task, func(w io.Writer) {
+		fmt.Fprintf(w, "Created task %d: %s\n", task.ID, task.Title)
+	})
+}
+
+func runList(a *app, args []string) error {
+	fs := a.flags("ls")
+	all := fs.Bool("all", false, "include completed tasks")
+	due := fs.String("due", "", "only tasks due on this day")
+	tag := fs.String("tag", "", "only tasks with this tag")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	var dueDay time.Time
+	if *due != "" {
+		var err error
+		if dueDay, err = dates.ParseDay(*due, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	tasks, err := a.api().ListTasks(a.ctx)
+	if err != nil {
+		return err
+	}
+
+	shown := []models.Task{}
+	for _, task := range tasks {
+		if task.Completed && !*all {
+			continue
+		}
+		if *tag != "" && !hasTag(task.Tags, *tag) {
+			continue
+		}
+		if !dueDay.IsZero() && (task.DueDate.Before(dueDay) || !task.DueDate.Before(dueDay.AddDate(0, 0, 1))) {
+			continue
+		}
+		shown = append(shown, task)
+	}
+	sortTasks(shown)
+
+	return a.print(shown, func(w io.Writer) {
+		fmt.Fprintln(w, "ID\tDONE\tPRIORITY\tDUE\tTITLE\tTAGS")
+		for _, task := range shown {
+			fmt.Fprintf(w, "%d\t[%s]\t%s\t%s\t%s\t%s\n",
+				task.ID, check(task.Completed), orDash(task.Priority), formatDay(task.DueDate), task.Title, orDash(task.Tags))
+		}
+	})
+}
+
+// sortTasks orders by due date, tasks without one last
+func sortTasks(tasks []models.Task) {
+	sort.SliceStable(tasks, func(i, j int) bool {
+		a, b := tasks[i].DueDate, tasks[j].DueDate
+		if a.IsZero() != b.IsZero() {
+			return b.IsZero()
+		}
+		if !a.Equal(b) {
+			return a.Before(b)
+		}
+		return tasks[i].ID < tasks[j].ID
+	})
+}
+
+func hasTag(tags, tag string) bool {
+	for _, t := range strings.Split(tags, ",") {
+		if strings.EqualFold(strings.TrimSpace(t), tag) {
+			return true
+		}
+	}
+	return false
+}
+
+func runShow(a *app, args []string) error {
+	args, err := parseFlags(a.flags("show"), args)
+	if err != nil {
+		return err
+	}
+	ids, err := parseIDs(args)
+	if err != nil || len(ids) != 1 {
+		return usageError("show")
+	}
+
+	task, err := a.api().GetTask(a.ctx, ids[0])
+	if err != nil {
+		return err
+	}
+	return a.print(task, func(w io.Writer) {
+		fmt.Fprintf(w, "ID\t%d\n", task.ID)
+		fmt.Fprintf(w, "Title\t%s\n", task.Title)
+		fmt.Fprintf(w, "Done\t%t\n", task.Completed)
+		fmt.Fprintf(w, "Priority\t%s\n", orDash(task.Priority))
+		if task.DueDate.IsZero() {
+			fmt.Fprintf(w, "Due\t-\n")
+		} else {
+			fmt.Fprintf(w, "Due\t%s\n", task.DueDate.Local().Format("Mon 2006-01-02 15:04"))
+		}
+		fmt.Fprintf(w, "Tags\t%s\n", orDash(task.Tags))
+		fmt.Fprintf(w, "Pomodoros\t%d/%d\n", task.CompletedPomodoros, task.EstimatedPomodoros)
+		if task.Recurrence != "" {
+			fmt.Fprintf(w, "Repeats\t%s\n", task.Recurrence)
+		}
+		if task.Description != "" {
+			fmt.Fprintf(w, "\n%s\n", task.Description)
+		}
+	})
+}
+
+func runDone(a *app, args []string) error {
+	return setCompleted(a, "done", args, true)
+}
+
+func runReopen(a *app, args []string) error {
+	return setCompleted(a, "reopen", args, false)
+}
+
+func setCompleted(a *app, name string, args []string, completed bool) error {
+	args, err := parseFlags(a.flags(name), args)
+	if err != nil {
+		return err
+	}
+	ids, err := parseIDs(args)
+	if err != nil || len(ids) == 0 {
+		return usageError(name)
+	}
+
+	api := a.api()
+	tasks := []*models.Task{}
+	for _, id := range ids {
+		task, err := api.PatchTask(a.ctx, id, map[string]interface{}{"completed": completed})
+		if err != nil {
+			return fmt.Errorf("task %d: %w", id, err)
+		}
+		tasks = append(tasks, task)
+	}
+	return a.print(tasks, func(w io.Writer) {
+		for _, task := range tasks {
+			fmt.Fprintf(w, "[%s] %d %s\n", check(task.Completed), task.ID, task.Title)
+		}
+	})
+}
+
+func runRemove(a *app, args []string) error {
+	fs := a.flags("rm")
+	withEvents := fs.Bool("with-events", false, "also trash the task's calendar events")
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	ids, err := parseIDs(args)
+	if err != nil || len(ids) == 0 {
+		return usageError("rm")
+	}
+
+	api := a.api()
+	for _, id := range ids {
+		if _, err := api.DeleteTask(a.ctx, id, *withEvents); err != nil {
+			return fmt.Errorf("task %d: %w", id, err)
+		}
+	}
+	return a.print(map[string]interface{}{"deleted": ids}, func(w io.Writer) {
+		fmt.Fprintf(w, "Moved %d task(s) to the trash\n", len(ids))
+	})
+}
+
+func parseIDs(args []string) ([]uint, error) {
+	ids := make([]uint, 0, len(args))
+	for _, arg := range args {
+		id, err := strconv.ParseUint(arg, 10, 32)
+		if err != nil || id == 0 {
+			return nil, fmt.Errorf("invalid ID %q", arg)
+		}
+		ids = append(ids, uint(id))
+	}
+	return ids, nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/rayzox/tickr-backend/dates"
+	"github.com/rayzox/tickr-backend/models"
+)
+
+func runToday(a *app, args []string) error {
+	if _, err := parseFlags(a.flags("today"), args); err != nil {
+		return err
+	}
+
+	dashboard, err := a.api().Dashboard(a.ctx)
+	if err != nil {
+		return err
+	}
+	sortTasks(dashboard.TodayTasks)
+
+	return a.print(dashboard, func(w io.Writer) {
+		fmt.Fprintf(w, "%s\n", dashboard.Date.Local().Format("Monday, January 2"))
+
+		if dashboard.ActiveFocus.ID != 0 {
+			fmt.Fprintf(w, "\nFocusing on %q since %s\n", dashboard.ActiveFocus.Task.Title, dashboard.ActiveFocus.StartTime.Local().Format("15:04"))
+		}
+
+		fmt.Fprintln(w, "\nTasks")
+		if len(dashboard.TodayTasks) == 0 {
+			fmt.Fprintln(w, "  nothing due today")
+		}
+		for _, task := range dashboard.TodayTasks {
+			fmt.Fprintf(w, "  [%s] %d\t%s\t%s\n", check(task.Completed), task.ID, task.Title, orDash(task.Priority))
+		}
+
+		fmt.Fprintln(w, "\nHabits")
+		for _, habit := range dashboard.TodayHabits {
+			fmt.Fprintf(w, "  [%s] %d\t%s\t%d day streak\n", check(habit.CompletedToday), habit.ID, habit.Name, habit.Streak)
+		}
+
+		if len(dashboard.TodayEvents) > 0 {
+			fmt.Fprintln(w, "\nEvents")
+			printEvents(w, dashboard.TodayEvents, "  ")
+		}
+
+		if len(dashboard.TodayPomodoros) > 0 {
+			minutes := 0
+			for _, pomodoro := range dashboard.TodayPomodoros {
+				minutes += pomodoro.Duration
+			}
+			fmt.Fprintf(w, "\n%d pomodoros, %d minutes\n", len(dashboard.TodayPomodoros), minutes)
+		}
+	})
+}
+
+func runEvents(a *app, args []string) error {
+	fs := a.flags("events")
+	from := fs.String("from", "today", "first day")
+	to := fs.String("to", "", "last day, a week after --from by default")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	start, err := dates.ParseDay(*from, time.Now())
+	if err != nil {
+		return err
+	}
+	end := start.AddDate(0, 0, 6)
+	if *to != "" {
+		if end, err = dates.ParseDay(*to, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	events, err := a.api().EventsInRange(a.ctx, start, end)
+	if err != nil {
+		return err
+	}
+	return a.print(events, func(w io.Writer) {
+		printEvents(w, events, "")
+	})
+}
+
+func printEvents(w io.Writer, events []models.Event, indent string) {
+	for _, event := range events {
+		when := event.EventDate.Local().Format("Mon Jan 2 15:04")
+		if event.AllDay {
+			when = event.EventDate.Local().Format("Mon Jan 2") + " all day"
+		}
+		fmt.Fprintf(w, "%s%d\t%s\t%s\t%s\n", indent, event.ID, when, event.Title, orDash(event.EventType))
+	}
+}
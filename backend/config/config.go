@@ -0,0 +1,232 @@
+// Package config holds the server's settings. They're read once at startup
+// from, in increasing precedence: the defaults, a JSON file (-config or
+// TICKR_CONFIG), TICKR_* environment variables and command line flags, then
+// validated so a typo stops the server instead of being silently ignored.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+type Config struct {
+	Addr            string   `json:"addr"`
+	Database        string   `json:"database"` // SQLite file
+	ShutdownTimeout Duration `json:"shutdown_timeout"`
+
+	// How long /readyz fails before the server stops taking requests on
+	// shutdown, more than the load balancer's health check interval. Nothing
+	// to wait for without one.
+	DrainDelay Duration `json:"drain_delay"`
+
+	// Proxies whose X-Forwarded-For is believed, e.g. for rate limiting. By
+	// default none, the client is whoever connected.
+	TrustedProxies []string `json:"trusted_proxies"`
+
+	Log      Log      `json:"log"`
+	CORS     CORS     `json:"cors"`
+	Security Security `json:"security"`
+	Pomodoro Pomodoro `json:"pomodoro"`
+	SMTP     SMTP     `json:"smtp"`
+
+	TrashRetentionDays   int      `json:"trash_retention_days"` // 0 keeps trash forever
+	UndoWindow           Duration `json:"undo_window"`
+	IdempotencyRetention Duration `json:"idempotency_retention"`
+	FocusStaleAfter      Duration `json:"focus_stale_after"`
+
+	// Shared secrets. Empty disables inbound email and /admin, but leaves
+	// /metrics open.
+	InboundToken string `json:"inbound_token"`
+	MetricsToken string `json:"metrics_token"`
+	AdminToken   string `json:"admin_token"`
+}
+
+type Log struct {
+	Level  string `json:"level"`  // debug, info, warn or error
+	Format string `json:"format"` // text or json
+}
+
+// CORS lists the other origins a browser may call the API from. None by
+// default, the frontend is served by the API itself.
+type CORS struct {
+	AllowedOrigins []string `json:"allowed_origins"` // "*" allows any
+}
+
+type Security struct {
+	MaxBodyBytes int64 `json:"max_body_bytes"`
+
+	// Per client, refilled steadily up to the burst. 0 disables it.
+	RateLimit int `json:"rate_limit"` // requests per minute
+	RateBurst int `json:"rate_burst"`
+
+	ContentSecurityPolicy string   `json:"content_security_policy"` // empty leaves it out
+	HSTSMaxAge            Duration `json:"hsts_max_age"`            // 0 leaves it out, set it when served over HTTPS
+
+	// CSRF rejects writes from other sites' pages, see Sec-Fetch-Site and
+	// Origin. Only the origins in cors.allowed_origins get through.
+	CSRF bool `json:"csrf"`
+}
+
+// Pomodoro is the default length of each phase, in minutes
+type Pomodoro struct {
+	WorkMinutes       int `json:"work_minutes"`
+	ShortBreakMinutes int `json:"short_break_minutes"`
+	LongBreakMinutes  int `json:"long_break_minutes"`
+}
+
+// SMTP is the mail server digests are sent through, no host disables them
+type SMTP struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	From     string `json:"from"`
+}
+
+// Default is what the server runs with when nothing is set: fine on a laptop,
+// a shared server wants at least the tokens, and its proxy trusted
+func Default() *Config {
+	return &Config{
+		Addr:            ":8080",
+		Database:        "tickr.db",
+		ShutdownTimeout: Duration{15 * time.Second},
+		Log:             Log{Level: "info", Format: "text"},
+		TrustedProxies:  []string{},
+		CORS:            CORS{AllowedOrigins: []string{}},
+		Security: Security{
+			MaxBodyBytes:          1 << 20,
+			RateLimit:             600,
+			RateBurst:             100,
+			ContentSecurityPolicy: "default-src 'self'; img-src 'self' data:; style-src 'self' 'unsafe-inline'; frame-ancestors 'none'; base-uri 'self'; form-action 'self'",
+			CSRF:                  true,
+		},
+		Pomodoro: Pomodoro{WorkMinutes: 25, ShortBreakMinutes: 5, LongBreakMinutes: 15},
+		SMTP:     SMTP{Port: 587, From: "tickr@localhost"},
+
+		TrashRetentionDays:   30,
+		UndoWindow:           Duration{5 * time.Minute},
+		IdempotencyRetention: Duration{24 * time.Hour},
+		FocusStaleAfter:      Duration{2 * time.Hour},
+	}
+}
+
+var current atomic.Pointer[Config]
+
+func init() {
+	current.Store(Default())
+}
+
+// Get is the configuration the server runs with, the defaults until Set
+func Get() *Config {
+	return current.Load()
+}
+
+func Set(cfg *Config) {
+	current.Store(cfg)
+}
+
+// Load builds the configuration from the command line args (without the
+// program name), the file and the environment. -h gives flag.ErrHelp after
+// printing every setting.
+func Load(args []string) (*Config, error) {
+	cfg := Default()
+	settings := cfg.settings()
+
+	// Flags are only collected here, they're applied last to win over the rest
+	fs := flag.NewFlagSet("tickr", flag.ContinueOnError)
+	path := fs.String("config", os.Getenv("TICKR_CONFIG"), "JSON config file (env TICKR_CONFIG)")
+	flagged := map[string]string{}
+	for _, s := range settings {
+		_, isBool := s.value.(*boolValue)
+		fs.Var(collected{s.key, flagged, isBool}, s.key, fmt.Sprintf("%s (env %s, default %q)", s.usage, s.env, s.value.String()))
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+
+	if *path != "" {
+		if err := cfg.loadFile(*path); err != nil {
+			return nil, err
+		}
+	}
+	for _, s := range settings {
+		if v := os.Getenv(s.env); v != "" {
+			if err := s.value.Set(v); err != nil {
+				return nil, fmt.Errorf("%s: %w", s.env, err)
+			}
+		}
+	}
+	for _, s := range settings {
+		if v, ok := flagged[s.key]; ok {
+			if err := s.value.Set(v); err != nil {
+				return nil, fmt.Errorf("-%s: %w", s.key, err)
+			}
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (c *Config) loadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("config file: %w", err)
+	}
+	defer f.Close()
+
+	// unknown keys are most likely typos
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(c); err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// Redacted is the configuration as it would be written in the file, with
+// the secrets that are set replaced
+func (c *Config) Redacted() map[string]interface{} {
+	data, _ := json.Marshal(c)
+	var out map[string]interface{}
+	json.Unmarshal(data, &out)
+
+	for _, s := range c.settings() {
+		if s.secret && s.value.String() != "" {
+			setKey(out, s.key, "[redacted]")
+		}
+	}
+	return out
+}
+
+// Duration is a time.Duration written as "90s" or "2h" in the file
+type Duration struct {
+	time.Duration
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return errors.New(`durations are strings like "90s" or "2h"`)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	d.Duration = parsed
+	return nil
+}
@@ -0,0 +1,235 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// setting is one value that can be set from the environment or a flag. The
+// key is its path in the file, and the flag's name.
+type setting struct {
+	key    string
+	env    string
+	usage  string
+	secret bool
+	value  value
+}
+
+// value is a flag.Value pointing into a Config
+type value interface {
+	String() string
+	Set(string) error
+}
+
+func (c *Config) settings() []setting {
+	return []setting{
+		{"addr", "TICKR_ADDR", "address to listen on", false, (*stringValue)(&c.Addr)},
+		{"database", "TICKR_DATABASE", "SQLite database file", false, (*stringValue)(&c.Database)},
+		{"shutdown_timeout", "TICKR_SHUTDOWN_TIMEOUT", "how long requests in flight get to finish on shutdown", false, (*durationValue)(&c.ShutdownTimeout.Duration)},
+		{"drain_delay", "TICKR_DRAIN_DELAY", "how long /readyz fails before shutting down, for load balancers to notice", false, (*durationValue)(&c.DrainDelay.Duration)},
+		{"trusted_proxies", "TICKR_TRUSTED_PROXIES", "comma separated IPs or CIDRs of reverse proxies", false, (*listValue)(&c.TrustedProxies)},
+		{"log.level", "TICKR_LOG_LEVEL", "debug, info, warn or error", false, (*stringValue)(&c.Log.Level)},
+		{"log.format", "TICKR_LOG_FORMAT", "text or json", false, (*stringValue)(&c.Log.Format)},
+		{"cors.allowed_origins", "TICKR_CORS_ALLOWED_ORIGINS", "comma separated origins the browser may call the API from, * for any", false, (*listValue)(&c.CORS.AllowedOrigins)},
+		{"security.max_body_bytes", "TICKR_SECURITY_MAX_BODY_BYTES", "largest request body accepted", false, (*int64Value)(&c.Security.MaxBodyBytes)},
+		{"security.rate_limit", "TICKR_SECURITY_RATE_LIMIT", "requests per minute per client, 0 disables the limit", false, (*intValue)(&c.Security.RateLimit)},
+		{"security.rate_burst", "TICKR_SECURITY_RATE_BURST", "requests a client can make at once", false, (*intValue)(&c.Security.RateBurst)},
+		{"security.content_security_policy", "TICKR_SECURITY_CONTENT_SECURITY_POLICY", "Content-Security-Policy header, empty leaves it out", false, (*stringValue)(&c.Security.ContentSecurityPolicy)},
+		{"security.hsts_max_age", "TICKR_SECURITY_HSTS_MAX_AGE", "Strict-Transport-Security max-age, 0 leaves it out", false, (*durationValue)(&c.Security.HSTSMaxAge.Duration)},
+		{"security.csrf", "TICKR_SECURITY_CSRF", "reject writes from other sites' pages", false, (*boolValue)(&c.Security.CSRF)},
+		{"pomodoro.work_minutes", "TICKR_POMODORO_WORK_MINUTES", "default length of a focus session", false, (*intValue)(&c.Pomodoro.WorkMinutes)},
+		{"pomodoro.short_break_minutes", "TICKR_POMODORO_SHORT_BREAK_MINUTES", "default length of a short break", false, (*intValue)(&c.Pomodoro.ShortBreakMinutes)},
+		{"pomodoro.long_break_minutes", "TICKR_POMODORO_LONG_BREAK_MINUTES", "default length of a long break", false, (*intValue)(&c.Pomodoro.LongBreakMinutes)},
+		{"smtp.host", "TICKR_SMTP_HOST", "mail server for digests, empty disables them", false, (*stringValue)(&c.SMTP.Host)},
+		{"smtp.port", "TICKR_SMTP_PORT", "mail server port", false, (*intValue)(&c.SMTP.Port)},
+		{"smtp.username", "TICKR_SMTP_USERNAME", "mail server user", false, (*stringValue)(&c.SMTP.Username)},
+		{"smtp.password", "TICKR_SMTP_PASSWORD", "mail server password", true, (*stringValue)(&c.SMTP.Password)},
+		{"smtp.from", "TICKR_SMTP_FROM", "sender of digests", false, (*stringValue)(&c.SMTP.From)},
+		{"trash_retention_days", "TICKR_TRASH_RETENTION_DAYS", "days deleted items are kept, 0 keeps them forever", false, (*intValue)(&c.TrashRetentionDays)},
+		{"undo_window", "TICKR_UNDO_WINDOW", "how long an undo token stays valid", false, (*durationValue)(&c.UndoWindow.Duration)},
+		{"idempotency_retention", "TICKR_IDEMPOTENCY_RETENTION", "how long idempotency keys are remembered", false, (*durationValue)(&c.IdempotencyRetention.Duration)},
+		{"focus_stale_after", "TICKR_FOCUS_STALE_AFTER", "how long past its planned end a focus session is closed", false, (*durationValue)(&c.FocusStaleAfter.Duration)},
+		{"inbound_token", "TICKR_INBOUND_TOKEN", "secret mail providers send with inbound emails, empty disables them", true, (*stringValue)(&c.InboundToken)},
+		{"metrics_token", "TICKR_METRICS_TOKEN", "bearer token for /metrics", true, (*stringValue)(&c.MetricsToken)},
+		{"admin_token", "TICKR_ADMIN_TOKEN", "bearer token for /admin, empty disables it", true, (*stringValue)(&c.AdminToken)},
+	}
+}
+
+type stringValue string
+
+func (v *stringValue) String() string     { return string(*v) }
+func (v *stringValue) Set(s string) error { *v = stringValue(s); return nil }
+
+type intValue int
+
+func (v *intValue) String() string { return strconv.Itoa(int(*v)) }
+
+func (v *intValue) Set(s string) error {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return fmt.Errorf("%q isn't a number", s)
+	}
+	*v = intValue(n)
+	return nil
+}
+
+type int64Value int64
+
+func (v *int64Value) String() string { return strconv.FormatInt(int64(*v), 10) }
+
+func (v *int64Value) Set(s string) error {
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%q isn't a number", s)
+	}
+	*v = int64Value(n)
+	return nil
+}
+
+type boolValue bool
+
+func (v *boolValue) String() string { return strconv.FormatBool(bool(*v)) }
+
+func (v *boolValue) Set(s string) error {
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return fmt.Errorf("%q isn't true or false", s)
+	}
+	*v = boolValue(b)
+	return nil
+}
+
+type durationValue time.Duration
+
+func (v *durationValue) String() string { return time.Duration(*v).String() }
+
+func (v *durationValue) Set(s string) error {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("%q isn't a duration like 90s or 2h", s)
+	}
+	*v = durationValue(d)
+	return nil
+}
+
+// listValue is comma separated
+type listValue []string
+
+func (v *listValue) String() string { return strings.Join(*v, ",") }
+
+func (v *listValue) Set(s string) error {
+	*v = nil
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*v = append(*v, item)
+		}
+	}
+	return nil
+}
+
+// collected keeps a flag's raw value for Load to apply after the file and
+// the environment
+type collected struct {
+	key     string
+	flagged map[string]string
+	isBool  bool
+}
+
+func (f collected) String() string     { return "" }
+func (f collected) Set(v string) error { f.flagged[f.key] = v; return nil }
+func (f collected) IsBoolFlag() bool   { return f.isBool }
+
+// setKey sets a dotted key like "smtp.password" in the decoded JSON of a Config
+func setKey(m map[string]interface{}, key string, v interface{}) {
+	parts := strings.Split(key, ".")
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := m[part].(map[string]interface{})
+		if !ok {
+			return
+		}
+		m = next
+	}
+	m[parts[len(parts)-1]] = v
+}
+
+// Validate reports every invalid setting at once
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, key, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+		}
+	}
+
+	_, port, err := net.SplitHostPort(c.Addr)
+	n, _ := strconv.Atoi(port)
+	check(err == nil && n >= 0 && n <= 65535, "addr", "%q isn't host:port, e.g. :8080 or 127.0.0.1:8080", c.Addr)
+	check(c.Database != "", "database", "can't be empty")
+	check(c.ShutdownTimeout.Duration > 0, "shutdown_timeout", "must be positive")
+	check(c.DrainDelay.Duration >= 0, "drain_delay", "can't be negative")
+
+	switch strings.ToLower(c.Log.Level) {
+	case "debug", "info", "warn", "error":
+	default:
+		check(false, "log.level", "%q isn't debug, info, warn or error", c.Log.Level)
+	}
+	switch strings.ToLower(c.Log.Format) {
+	case "text", "json":
+	default:
+		check(false, "log.format", "%q isn't text or json", c.Log.Format)
+	}
+
+	for _, proxy := range c.TrustedProxies {
+		check(validIPOrCIDR(proxy), "trusted_proxies", "%q isn't an IP or CIDR", proxy)
+	}
+	for _, origin := range c.CORS.AllowedOrigins {
+		check(validOrigin(origin), "cors.allowed_origins", "%q isn't an origin like https://tickr.example.com", origin)
+	}
+
+	check(c.Security.MaxBodyBytes > 0, "security.max_body_bytes", "must be positive")
+	check(c.Security.RateLimit >= 0, "security.rate_limit", "can't be negative")
+	check(c.Security.RateLimit == 0 || c.Security.RateBurst >= 1, "security.rate_burst", "must be at least 1")
+	check(c.Security.HSTSMaxAge.Duration >= 0, "security.hsts_max_age", "can't be negative")
+
+	check(validMinutes(c.Pomodoro.WorkMinutes), "pomodoro.work_minutes", "must be between 1 and 240")
+	check(validMinutes(c.Pomodoro.ShortBreakMinutes), "pomodoro.short_break_minutes", "must be between 1 and 240")
+	check(validMinutes(c.Pomodoro.LongBreakMinutes), "pomodoro.long_break_minutes", "must be between 1 and 240")
+
+	check(c.SMTP.Port >= 1 && c.SMTP.Port <= 65535, "smtp.port", "%d isn't a port", c.SMTP.Port)
+	if c.SMTP.Host != "" {
+		_, err := mail.ParseAddress(c.SMTP.From)
+		check(err == nil, "smtp.from", "%q isn't an email address", c.SMTP.From)
+	}
+
+	check(c.TrashRetentionDays >= 0, "trash_retention_days", "can't be negative")
+	check(c.UndoWindow.Duration > 0, "undo_window", "must be positive")
+	check(c.IdempotencyRetention.Duration > 0, "idempotency_retention", "must be positive")
+	check(c.FocusStaleAfter.Duration > 0, "focus_stale_after", "must be positive")
+
+	return errors.Join(errs...)
+}
+
+func validMinutes(n int) bool {
+	return n >= 1 && n <= 240
+}
+
+func validIPOrCIDR(s string) bool {
+	if _, _, err := net.ParseCIDR(s); err == nil {
+		return true
+	}
+	return net.ParseIP(s) != nil
+}
+
+func validOrigin(origin string) bool {
+	if origin == "*" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" && u.Path == "" && u.RawQuery == ""
+}
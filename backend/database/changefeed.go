@@ -0,0 +1,159 @@
+package database
+
+import (
+	"reflect"
+
+	"github.com/rayzox/tickr-backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Tables whose writes are recorded in the change feed, with the entity name clients see
+var trackedTables = map[string]string{
+	"tasks":             "task",
+	"habits":            "habit",
+	"events":            "event",
+	"pomodoro_sessions": "pomodoro",
+	"focus_sessions":    "focus",
+}
+
+const changedIDsKey = "tickr:changed_ids"
+
+// registerChangeFeed hooks into gorm so every create, update and delete on a
+// tracked table appends to the changes table in the same transaction. Raw SQL
+// (Exec) bypasses the feed.
+func registerChangeFeed(db *gorm.DB) {
+	db.Callback().Create().After("gorm:create").Register("tickr:changefeed_create", func(tx *gorm.DB) {
+		if _, ok := trackedEntity(tx); ok {
+			recordChanges(tx, "create", primaryKeys(tx))
+		}
+	})
+
+	db.Callback().Update().Before("gorm:update").Register("tickr:changefeed_collect_update", collectChangedIDs)
+	db.Callback().Update().After("gorm:update").Register("tickr:changefeed_update", func(tx *gorm.DB) {
+		recordCollected(tx, "update")
+	})
+
+	db.Callback().Delete().Before("gorm:delete").Register("tickr:changefeed_collect_delete", collectChangedIDs)
+	db.Callback().Delete().After("gorm:delete").Register("tickr:changefeed_delete", func(tx *gorm.DB) {
+		recordCollected(tx, "delete")
+	})
+}
+
+func trackedEntity(tx *gorm.DB) (string, bool) {
+	if tx.Statement.Schema == nil {
+		return "", false
+	}
+	entity, ok := trackedTables[tx.Statement.Schema.Table]
+	return entity, ok
+}
+
+// primaryKeys reads the IDs of the model (or slice of models) the statement works on
+func primaryKeys(tx *gorm.DB) []uint {
+	field := tx.Statement.Schema.PrioritizedPrimaryField
+	if field == nil {
+		return nil
+	}
+
+	var ids []uint
+	collect := func(v reflect.Value) {
+		if value, zero := field.ValueOf(tx.Statement.Context, v); !zero {
+			if id, ok := value.(uint); ok {
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	rv := tx.Statement.ReflectValue
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			collect(reflect.Indirect(rv.Index(i)))
+		}
+	case reflect.Struct:
+		collect(rv)
+	}
+	return ids
+}
+
+// collectChangedIDs runs before updates and deletes: the rows a statement like
+// Where("1 = 1").Delete(...) touches are only known before it runs
+func collectChangedIDs(tx *gorm.DB) {
+	if _, ok := trackedEntity(tx); !ok || tx.Error != nil {
+		return
+	}
+
+	ids := primaryKeys(tx)
+	if len(ids) == 0 {
+		where, ok := tx.Statement.Clauses["WHERE"].Expression.(clause.Where)
+		if !ok || len(where.Exprs) == 0 {
+			return
+		}
+
+		query := tx.Session(&gorm.Session{NewDB: true}).Model(reflect.New(tx.Statement.Schema.ModelType).Interface())
+		if tx.Statement.Unscoped {
+			query = query.Unscoped()
+		}
+		if err := query.Clauses(where).Pluck("id", &ids).Error; err != nil {
+			tx.AddError(err)
+			return
+		}
+	}
+
+	tx.InstanceSet(changedIDsKey, ids)
+}
+
+func recordCollected(tx *gorm.DB, operation string) {
+	if tx.Error != nil || tx.Statement.RowsAffected == 0 {
+		return
+	}
+	if ids, ok := tx.InstanceGet(changedIDsKey); ok {
+		recordChanges(tx, operation, ids.([]uint))
+	}
+}
+
+func recordChanges(tx *gorm.DB, operation string, ids []uint) {
+	entity, ok := trackedEntity(tx)
+	if !ok || tx.Error != nil || len(ids) == 0 {
+		return
+	}
+
+	changes := make([]models.Change, 0, len(ids))
+	for _, id := range ids {
+		changes = append(changes, models.Change{Entity: entity, EntityID: id, Operation: operation})
+	}
+
+	if err := tx.Session(&gorm.Session{NewDB: true}).Create(&changes).Error; err != nil {
+		tx.AddError(err)
+	}
+}
+
+// SeedChangeFeed records a create for every existing row the first time the
+// feed is used, so clients doing their first sync get data written before it existed
+func SeedChangeFeed() error {
+	var count int64
+	if err := DB.Model(&models.Change{}).Count(&count).Error; err != nil || count > 0 {
+		return err
+	}
+
+	return DB.Transaction(func(tx *gorm.DB) error {
+		for table, entity := range trackedTables {
+			var ids []uint
+			if err := tx.Table(table).Where("deleted_at IS NULL").Order("id").Pluck("id", &ids).Error; err != nil {
+				return err
+			}
+			if len(ids) == 0 {
+				continue
+			}
+
+			changes := make([]models.Change, 0, len(ids))
+			for _, id := range ids {
+				changes = append(changes, models.Change{Entity: entity, EntityID: id, Operation: "create"})
+			}
+			if err := tx.CreateInBatches(&changes, 500).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,113 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rayzox/tickr-backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Relationships whose foreign keys carry ON DELETE rules, as (model, relationship field)
+var enforcedRelationships = []struct {
+	model interface{}
+	field string
+}{
+	{&models.Task{}, "CalendarEvent"},
+	{&models.Task{}, "PomodoroSessions"},
+	{&models.Event{}, "Task"},
+	{&models.Habit{}, "CalendarEvents"},
+	{&models.FocusSession{}, "Task"},
+}
+
+// MigrateConstraints brings foreign keys created before they had ON DELETE
+// rules up to date and clears references to rows that no longer exist, so
+// enforcing foreign keys doesn't break writes to old data. AutoMigrate only
+// adds missing constraints and never changes existing ones.
+func MigrateConstraints() error {
+	return DB.Connection(func(conn *gorm.DB) error {
+		conn = conn.Session(&gorm.Session{})
+
+		// Rebuilding a table drops it, which would fire the very cascades we're adding
+		if err := conn.Exec("PRAGMA foreign_keys = OFF").Error; err != nil {
+			return err
+		}
+		defer conn.Exec("PRAGMA foreign_keys = ON")
+
+		if err := clearDanglingReferences(conn); err != nil {
+			return err
+		}
+
+		rebuilt := false
+		for _, rel := range enforcedRelationships {
+			stmt := &gorm.Statement{DB: conn}
+			if err := stmt.Parse(rel.model); err != nil {
+				return err
+			}
+			relationship, ok := stmt.Schema.Relationships.Relations[rel.field]
+			if !ok {
+				return fmt.Errorf("unknown relationship %s.%s", stmt.Schema.Name, rel.field)
+			}
+			constraint := relationship.ParseConstraint()
+			if constraint == nil || constraintUpToDate(conn, constraint) {
+				continue
+			}
+
+			migrator := conn.Migrator()
+			if migrator.HasConstraint(rel.model, rel.field) {
+				if err := migrator.DropConstraint(rel.model, rel.field); err != nil {
+					return err
+				}
+			}
+			if err := migrator.CreateConstraint(rel.model, rel.field); err != nil {
+				return err
+			}
+			rebuilt = true
+		}
+
+		// Rebuilding a table loses its indexes, let AutoMigrate put them back
+		if rebuilt {
+			return conn.AutoMigrate(&models.Task{}, &models.Event{}, &models.PomodoroSession{}, &models.FocusSession{})
+		}
+		return nil
+	})
+}
+
+// constraintUpToDate checks the table's DDL already declares the constraint's ON DELETE rule
+func constraintUpToDate(conn *gorm.DB, constraint *schema.Constraint) bool {
+	var ddl string
+	conn.Raw("SELECT sql FROM sqlite_master WHERE type = 'table' AND name = ?", constraint.Schema.Table).Row().Scan(&ddl)
+
+	start := strings.Index(ddl, "CONSTRAINT `"+constraint.Name+"`")
+	if start < 0 {
+		return false
+	}
+	definition := ddl[start+len("CONSTRAINT"):]
+	if end := strings.Index(definition, "CONSTRAINT"); end >= 0 {
+		definition = definition[:end]
+	}
+	return strings.Contains(definition, "ON DELETE "+constraint.OnDelete)
+}
+
+func clearDanglingReferences(conn *gorm.DB) error {
+	db := conn.Unscoped().Session(&gorm.Session{})
+	steps := []*gorm.DB{
+		db.Model(&models.Task{}).Where("calendar_event_id IS NOT NULL AND calendar_event_id NOT IN (?)", db.Table("events").Select("id")).
+			Update("calendar_event_id", nil),
+		db.Model(&models.Event{}).Where("task_id IS NOT NULL AND task_id NOT IN (?)", db.Table("tasks").Select("id")).
+			Update("task_id", nil),
+		db.Model(&models.PomodoroSession{}).Where("task_id IS NOT NULL AND task_id NOT IN (?)", db.Table("tasks").Select("id")).
+			Update("task_id", nil),
+		db.Where("habit_id IS NOT NULL AND habit_id NOT IN (?)", db.Table("habits").Select("id")).
+			Delete(&models.Event{}),
+		db.Where("task_id NOT IN (?)", db.Table("tasks").Select("id")).
+			Delete(&models.FocusSession{}),
+	}
+	for _, step := range steps {
+		if step.Error != nil {
+			return step.Error
+		}
+	}
+	return nil
+}
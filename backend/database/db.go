@@ -1,20 +1,32 @@
 package database
 
 import (
-	"log"
+	"log/slog"
+	"os"
 
+	"github.com/rayzox/tickr-backend/logging"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
 var DB *gorm.DB
 
-func ConnectDatabase() {
-	db, err := gorm.Open(sqlite.Open("tickr.db"), &gorm.Config{})
+// ConnectDatabase opens the SQLite file at path, creating it if needed
+func ConnectDatabase(path string) {
+	// foreign keys are off by default in SQLite, the ON DELETE rules in models/ rely on them.
+	// WAL lets reads go on during a write, and writers wait for each other (busy
+	// timeout) instead of failing with "database is locked". Transactions take the
+	// write lock up front so two of them can't deadlock upgrading from a read.
+	dsn := path + "?_foreign_keys=on&_journal_mode=WAL&_busy_timeout=5000&_txlock=immediate"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{TranslateError: true, Logger: logging.Gorm()})
 	if err != nil {
-		log.Fatal("Failed to connect database:", err)
+		slog.Error("Failed to connect database", "error", err)
+		os.Exit(1)
 	}
 
+	registerChangeFeed(db)
+	registerMetrics(db)
+
 	DB = db
-	log.Println("Database connected successfully")
+	slog.Info("Database connected", "path", path)
 }
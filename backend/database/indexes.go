@@ -0,0 +1,32 @@
+package database
+
+import (
+	"time"
+
+	"github.com/rayzox/tickr-backend/models"
+	"gorm.io/gorm"
+)
+
+// MigrateIndexes creates the indexes gorm tags can't express
+func MigrateIndexes() error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		// Only one focus session may be running at a time. Older data can have
+		// several open sessions, keep the latest and close the rest.
+		var open []uint
+		if err := tx.Model(&models.FocusSession{}).
+			Where("end_time IS NULL").
+			Order("start_time DESC, id DESC").
+			Pluck("id", &open).Error; err != nil {
+			return err
+		}
+		if len(open) > 1 {
+			if err := tx.Model(&models.FocusSession{}).Where("id IN ?", open[1:]).
+				Updates(map[string]interface{}{"end_time": time.Now(), "completed": false}).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_focus_sessions_active " +
+			"ON focus_sessions((end_time IS NULL)) WHERE end_time IS NULL AND deleted_at IS NULL").Error
+	})
+}
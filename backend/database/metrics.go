@@ -0,0 +1,49 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"github.com/rayzox/tickr-backend/metrics"
+	"gorm.io/gorm"
+)
+
+const queryStartKey = "tickr:query_start"
+
+// registerMetrics times every statement gorm runs, by operation and table
+func registerMetrics(db *gorm.DB) {
+	cb := db.Callback()
+	cb.Create().Before("*").Register("tickr:metrics_start_create", startQueryTimer)
+	cb.Create().After("*").Register("tickr:metrics_create", observeQuery("create"))
+	cb.Query().Before("*").Register("tickr:metrics_start_query", startQueryTimer)
+	cb.Query().After("*").Register("tickr:metrics_query", observeQuery("query"))
+	cb.Update().Before("*").Register("tickr:metrics_start_update", startQueryTimer)
+	cb.Update().After("*").Register("tickr:metrics_update", observeQuery("update"))
+	cb.Delete().Before("*").Register("tickr:metrics_start_delete", startQueryTimer)
+	cb.Delete().After("*").Register("tickr:metrics_delete", observeQuery("delete"))
+	cb.Row().Before("*").Register("tickr:metrics_start_row", startQueryTimer)
+	cb.Row().After("*").Register("tickr:metrics_row", observeQuery("row"))
+	cb.Raw().Before("*").Register("tickr:metrics_start_raw", startQueryTimer)
+	cb.Raw().After("*").Register("tickr:metrics_raw", observeQuery("raw"))
+}
+
+func startQueryTimer(tx *gorm.DB) {
+	tx.InstanceSet(queryStartKey, time.Now())
+}
+
+func observeQuery(operation string) func(*gorm.DB) {
+	return func(tx *gorm.DB) {
+		start, ok := tx.InstanceGet(queryStartKey)
+		if !ok {
+			return
+		}
+		table := tx.Statement.Table
+		if table == "" {
+			table = "none" // raw SQL
+		}
+		metrics.DBQueryDuration.Observe(time.Since(start.(time.Time)).Seconds(), operation, table)
+		if tx.Error != nil && !errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+			metrics.DBQueryErrors.Inc(operation, table)
+		}
+	}
+}
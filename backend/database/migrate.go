@@ -0,0 +1,98 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/rayzox/tickr-backend/models"
+)
+
+// tables are the models AutoMigrate keeps in sync, in the order they're created
+var tables = []interface{}{
+	&models.Task{},
+	&models.Habit{},
+	&models.Event{},
+	&models.PomodoroSession{},
+	&models.FocusSession{},
+	&models.AuditLog{},
+	&models.UndoToken{},
+	&models.IdempotencyKey{},
+	&models.Change{},
+	&models.JobRun{},
+	&models.Reminder{},
+	&models.Notification{},
+	&models.Webhook{},
+	&models.WebhookDelivery{},
+	&models.DigestSubscription{},
+	&models.InboundSender{},
+}
+
+var migrated atomic.Bool
+
+// Migrate brings the schema up to date: tables and the data they're missing,
+// then the foreign keys and indexes AutoMigrate can't express, then the change
+// feed's seed
+func Migrate() error {
+	for _, table := range tables {
+		if err := DB.AutoMigrate(table); err != nil {
+			return fmt.Errorf("migrate %T: %w", table, err)
+		}
+	}
+	if err := backfillHabits(); err != nil {
+		return fmt.Errorf("backfill habits: %w", err)
+	}
+	if err := MigrateConstraints(); err != nil {
+		return fmt.Errorf("migrate foreign keys: %w", err)
+	}
+	if err := MigrateIndexes(); err != nil {
+		return fmt.Errorf("migrate indexes: %w", err)
+	}
+	if err := SeedChangeFeed(); err != nil {
+		return fmt.Errorf("seed change feed: %w", err)
+	}
+
+	migrated.Store(true)
+	return nil
+}
+
+// backfillHabits sets last_completed_at on habits checked before it existed.
+// Their last update is the best guess there is, and the habit rollover can
+// then go by last_completed_at alone. It's raw SQL so updated_at, and with it
+// the ETags, stay the same.
+func backfillHabits() error {
+	return DB.Exec("UPDATE habits SET last_completed_at = updated_at "+
+		"WHERE last_completed_at IS NULL AND (completed_today = ? OR streak > 0)", true).Error
+}
+
+// CheckSchema reports migrations that haven't run in this process, or tables
+// missing from the database since
+func CheckSchema() error {
+	if !migrated.Load() {
+		return fmt.Errorf("migrations pending")
+	}
+	for _, table := range tables {
+		if !DB.Migrator().HasTable(table) {
+			return fmt.Errorf("table for %T is missing", table)
+		}
+	}
+	return nil
+}
+
+// Ping checks the database answers
+func Ping(ctx context.Context) error {
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// Close closes the connection pool, once nothing runs queries anymore
+func Close() error {
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
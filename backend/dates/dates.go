@@ -0,0 +1,65 @@
+// Package dates understands the short due dates people type, like "friday" or "tomorrow"
+package dates
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "sun": time.Sunday,
+	"monday": time.Monday, "mon": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday, "tues": time.Tuesday,
+	"wednesday": time.Wednesday, "wed": time.Wednesday,
+	"thursday": time.Thursday, "thu": time.Thursday, "thurs": time.Thursday,
+	"friday": time.Friday, "fri": time.Friday,
+	"saturday": time.Saturday, "sat": time.Saturday,
+}
+
+// ParseDay returns the start of the day s refers to, relative to now and in
+// now's timezone. It accepts "today", "tomorrow", weekday names (the next one,
+// today included), "next-week" (next Monday), offsets like "3d" or "2w", and
+// dates as 2006-01-02.
+func ParseDay(s string, now time.Time) (time.Time, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	switch s {
+	case "today", "tod":
+		return today, nil
+	case "tomorrow", "tom", "tmr":
+		return today.AddDate(0, 0, 1), nil
+	case "next-week", "nextweek":
+		return today.AddDate(0, 0, daysUntil(today.Weekday(), time.Monday, false)), nil
+	}
+
+	if weekday, ok := weekdays[s]; ok {
+		return today.AddDate(0, 0, daysUntil(today.Weekday(), weekday, true)), nil
+	}
+
+	if n := len(s); n > 1 && (s[n-1] == 'd' || s[n-1] == 'w') {
+		if count, err := strconv.Atoi(strings.TrimPrefix(s[:n-1], "+")); err == nil && count >= 0 {
+			if s[n-1] == 'w' {
+				count *= 7
+			}
+			return today.AddDate(0, 0, count), nil
+		}
+	}
+
+	if day, err := time.ParseInLocation("2006-01-02", s, now.Location()); err == nil {
+		return day, nil
+	}
+
+	return time.Time{}, fmt.Errorf("unrecognized date %q", s)
+}
+
+// daysUntil counts the days from one weekday to the next occurrence of another
+func daysUntil(from, to time.Weekday, includeToday bool) int {
+	days := (int(to) - int(from) + 7) % 7
+	if days == 0 && !includeToday {
+		days = 7
+	}
+	return days
+}
@@ -0,0 +1,184 @@
+// Package digest renders the daily and weekly summary emails and sends them over SMTP
+package digest
+
+import (
+	"bytes"
+	"crypto/rand"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	htmltemplate "html/template"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/rayzox/tickr-backend/config"
+	"github.com/rayzox/tickr-backend/database"
+	"github.com/rayzox/tickr-backend/models"
+	"github.com/rayzox/tickr-backend/reports"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+var (
+	htmlTemplates = htmltemplate.Must(htmltemplate.ParseFS(templateFS, "templates/*.html"))
+	textTemplates = texttemplate.Must(texttemplate.ParseFS(templateFS, "templates/*.txt"))
+)
+
+// Kinds of digest: the morning summary and the Sunday recap of the week that just ended
+const (
+	Daily  = "daily"
+	Weekly = "weekly"
+)
+
+// Digest is a rendered email, both as HTML and plain text
+type Digest struct {
+	Kind    string `json:"kind"`
+	Subject string `json:"subject"`
+	HTML    string `json:"html"`
+	Text    string `json:"text"`
+}
+
+// Render builds the digest as it would be sent at now, in now's timezone
+func Render(kind string, now time.Time) (*Digest, error) {
+	var data interface{}
+	var subject string
+
+	switch kind {
+	case Daily:
+		dashboard, err := reports.DashboardFor(now)
+		if err != nil {
+			return nil, err
+		}
+		data = dashboard
+		subject = "Your day: " + now.Format("Monday, January 2")
+	case Weekly:
+		// Sent on Sunday, about the week that ended yesterday
+		weekly, err := reports.WeeklyFor(now.AddDate(0, 0, -1))
+		if err != nil {
+			return nil, err
+		}
+		data = weekly
+		subject = "Your week in review (" + weekly.WeekStart + ")"
+	default:
+		return nil, fmt.Errorf("unknown digest %q", kind)
+	}
+
+	var html, text bytes.Buffer
+	if err := htmlTemplates.ExecuteTemplate(&html, kind+".html", data); err != nil {
+		return nil, err
+	}
+	if err := textTemplates.ExecuteTemplate(&text, kind+".txt", data); err != nil {
+		return nil, err
+	}
+
+	return &Digest{Kind: kind, Subject: subject, HTML: html.String(), Text: text.String()}, nil
+}
+
+// SMTPConfig is the mail server digests are sent through
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// CurrentSMTPConfig is the smtp section of the server's config
+func CurrentSMTPConfig() SMTPConfig {
+	return SMTPConfig(config.Get().SMTP)
+}
+
+// Enabled reports whether a mail server is configured at all
+func (c SMTPConfig) Enabled() bool {
+	return c.Host != ""
+}
+
+// Send emails the digest to one address. The server's STARTTLS is used when it
+// offers it, and credentials are only sent over TLS or to localhost.
+func Send(cfg SMTPConfig, to string, d *Digest) error {
+	// Addresses can come with a name, "Tickr <tickr@example.com>", which only
+	// belongs in the headers and not in MAIL FROM / RCPT TO
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return fmt.Errorf("invalid sender %q: %w", cfg.From, err)
+	}
+	rcpt, err := mail.ParseAddress(to)
+	if err != nil {
+		return fmt.Errorf("invalid recipient %q: %w", to, err)
+	}
+
+	msg, err := message(from, rcpt, d)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	}
+
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+	return smtp.SendMail(addr, auth, from.Address, []string{rcpt.Address}, msg)
+}
+
+// Deliver renders the digest for a subscription, sends it and records when it went out
+func Deliver(sub models.DigestSubscription, kind string, now time.Time) error {
+	d, err := Render(kind, now)
+	if err != nil {
+		return err
+	}
+	if err := Send(CurrentSMTPConfig(), sub.Email, d); err != nil {
+		return err
+	}
+
+	column := "last_daily_sent_at"
+	if kind == Weekly {
+		column = "last_weekly_sent_at"
+	}
+	return database.DB.Model(&sub).Update(column, now).Error
+}
+
+// message builds a multipart/alternative email, mail clients show the richest part they support
+func message(from, to *mail.Address, d *Digest) ([]byte, error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	boundary := "tickr-" + hex.EncodeToString(buf)
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", from.String())
+	fmt.Fprintf(&msg, "To: %s\r\n", to.String())
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", d.Subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", boundary)
+
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain", d.Text},
+		{"text/html", d.HTML},
+	} {
+		fmt.Fprintf(&msg, "--%s\r\n", boundary)
+		fmt.Fprintf(&msg, "Content-Type: %s; charset=utf-8\r\n", part.contentType)
+		fmt.Fprintf(&msg, "Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+		qp := quotedprintable.NewWriter(&msg)
+		if _, err := qp.Write([]byte(part.body)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+		msg.WriteString("\r\n")
+	}
+	fmt.Fprintf(&msg, "--%s--\r\n", boundary)
+
+	return msg.Bytes(), nil
+}
@@ -0,0 +1,205 @@
+package digest
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rayzox/tickr-backend/config"
+	"github.com/rayzox/tickr-backend/database"
+	"github.com/rayzox/tickr-backend/models"
+)
+
+// Sunday morning, when both digests go out
+var sunday = time.Date(2026, 10, 18, 7, 0, 0, 0, time.UTC)
+
+func testDB(t *testing.T) {
+	t.Helper()
+	previous := database.DB
+	database.ConnectDatabase(filepath.Join(t.TempDir(), "tickr.db"))
+	if err := database.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		database.Close()
+		database.DB = previous
+	})
+
+	database.DB.Create(&models.Habit{Name: "Read", Streak: 3})
+	database.DB.Create(&models.PomodoroSession{Phase: "work", Duration: 25, CompletedAt: time.Date(2026, 10, 14, 10, 0, 0, 0, time.UTC)})
+}
+
+func TestRender(t *testing.T) {
+	testDB(t)
+
+	for _, tt := range []struct {
+		kind, subject string
+		text, html    []string
+	}{
+		{Daily, "Your day: Sunday, October 18",
+			[]string{"Sunday, October 18", "- Read (3 day streak)", "Nothing due today."},
+			[]string{"Read"}},
+		{Weekly, "Your week in review (2026-10-11)",
+			[]string{"week of 2026-10-11", "1 pomodoros (25 focused minutes)", "Wednesday"},
+			[]string{"2026-10-11"}},
+	} {
+		t.Run(tt.kind, func(t *testing.T) {
+			d, err := Render(tt.kind, sunday)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d.Subject != tt.subject {
+				t.Errorf("subject = %q, want %q", d.Subject, tt.subject)
+			}
+			for _, want := range tt.text {
+				if !strings.Contains(d.Text, want) {
+					t.Errorf("text is missing %q:\n%s", want, d.Text)
+				}
+			}
+			for _, want := range tt.html {
+				if !strings.Contains(d.HTML, want) {
+					t.Errorf("html is missing %q:\n%s", want, d.HTML)
+				}
+			}
+		})
+	}
+
+	if _, err := Render("monthly", sunday); err == nil {
+		t.Error("monthly: no error")
+	}
+}
+
+// smtpServer accepts one message and hands back its DATA
+func smtpServer(t *testing.T) (port int, received <-chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	messages := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		reply := func(line string) { fmt.Fprintf(conn, "%s\r\n", line) }
+		reply("220 localhost ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch command := strings.ToUpper(strings.Fields(line + " x")[0]); command {
+			case "EHLO", "HELO":
+				reply("250 localhost")
+			case "DATA":
+				reply("354 go ahead")
+				var data strings.Builder
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if line == ".\r\n" {
+						break
+					}
+					data.WriteString(strings.TrimPrefix(line, "."))
+				}
+				messages <- data.String()
+				reply("250 queued")
+			case "QUIT":
+				reply("221 bye")
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+
+	return ln.Addr().(*net.TCPAddr).Port, messages
+}
+
+func TestDeliver(t *testing.T) {
+	testDB(t)
+	port, received := smtpServer(t)
+
+	previous := config.Get()
+	cfg := config.Default()
+	cfg.SMTP = config.SMTP{Host: "127.0.0.1", Port: port, From: "Tickr <tickr@example.com>"}
+	config.Set(cfg)
+	t.Cleanup(func() { config.Set(previous) })
+
+	sub := models.DigestSubscription{Email: "me@example.com", Daily: true, Weekly: true}
+	database.DB.Create(&sub)
+
+	if err := Deliver(sub, Weekly, sunday); err != nil {
+		t.Fatal(err)
+	}
+
+	var data string
+	select {
+	case data = <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if to := msg.Header.Get("To"); to != "<me@example.com>" {
+		t.Errorf("To = %q", to)
+	}
+	if subject, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject")); subject != "Your week in review (2026-10-11)" {
+		t.Errorf("Subject = %q", subject)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q", msg.Header.Get("Content-Type"))
+	}
+	parts := map[string]string{}
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(part) // quoted-printable is decoded by the reader
+		if err != nil {
+			t.Fatal(err)
+		}
+		contentType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		parts[contentType] = string(body)
+	}
+	if !strings.Contains(parts["text/plain"], "1 pomodoros") {
+		t.Errorf("text/plain part = %q", parts["text/plain"])
+	}
+	if !strings.Contains(parts["text/html"], "<") {
+		t.Errorf("text/html part = %q", parts["text/html"])
+	}
+
+	database.DB.First(&sub, sub.ID)
+	if sub.LastWeeklySentAt == nil || !sub.LastWeeklySentAt.Equal(sunday) {
+		t.Errorf("last_weekly_sent_at = %v, want %v", sub.LastWeeklySentAt, sunday)
+	}
+	if sub.LastDailySentAt != nil {
+		t.Errorf("last_daily_sent_at = %v, want nil", sub.LastDailySentAt)
+	}
+}
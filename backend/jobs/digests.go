@@ -0,0 +1,70 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/rayzox/tickr-backend/database"
+	"github.com/rayzox/tickr-backend/digest"
+	"github.com/rayzox/tickr-backend/models"
+)
+
+// SendDigests emails the daily digest once the subscription's send time has
+// passed, and the weekly recap on Sundays. Each goes out at most once a day;
+// one missed because the server was down is sent when it's back, the same day.
+func SendDigests(ctx context.Context) error {
+	cfg := digest.CurrentSMTPConfig()
+	if !cfg.Enabled() {
+		return nil
+	}
+
+	db := database.DB.WithContext(ctx)
+
+	var subscriptions []models.DigestSubscription
+	if err := db.Where("daily = ? OR weekly = ?", true, true).Find(&subscriptions).Error; err != nil {
+		return err
+	}
+
+	var firstErr error
+	for _, sub := range subscriptions {
+		now := time.Now().In(location(sub.Timezone))
+		if now.Before(SendTimeOn(sub, now)) {
+			continue
+		}
+
+		due := map[string]bool{
+			digest.Daily:  sub.Daily && !sameDay(sub.LastDailySentAt, now),
+			digest.Weekly: sub.Weekly && now.Weekday() == time.Sunday && !sameDay(sub.LastWeeklySentAt, now),
+		}
+		for _, kind := range []string{digest.Daily, digest.Weekly} {
+			if !due[kind] {
+				continue
+			}
+			if err := digest.Deliver(sub, kind, now); err != nil {
+				slog.Error("Failed to send digest", "kind", kind, "email", sub.Email, "error", err)
+				if firstErr == nil {
+					firstErr = err
+				}
+			}
+		}
+	}
+
+	return firstErr
+}
+
+// SendTimeOn is when the subscription's digests go out on now's day
+func SendTimeOn(sub models.DigestSubscription, now time.Time) time.Time {
+	sendTime, err := time.Parse("15:04", sub.SendTime)
+	if err != nil {
+		sendTime, _ = time.Parse("15:04", "07:00")
+	}
+	return time.Date(now.Year(), now.Month(), now.Day(), sendTime.Hour(), sendTime.Minute(), 0, 0, now.Location())
+}
+
+func sameDay(t *time.Time, now time.Time) bool {
+	if t == nil {
+		return false
+	}
+	return startOfDay(t.In(now.Location())).Equal(startOfDay(now))
+}
@@ -0,0 +1,51 @@
+package jobs
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/rayzox/tickr-backend/config"
+	"github.com/rayzox/tickr-backend/database"
+	"github.com/rayzox/tickr-backend/models"
+)
+
+// FocusStaleAfter is how long past its planned end a focus session can stay
+// open before it's closed automatically, focus_stale_after in the config
+func FocusStaleAfter() time.Duration {
+	return config.Get().FocusStaleAfter.Duration
+}
+
+// CloseStaleFocusSessions ends sessions that were abandoned, e.g. when the app
+// was closed mid-session. They're closed at their planned end and not counted
+// as completed.
+func CloseStaleFocusSessions(ctx context.Context) error {
+	db := database.DB.WithContext(ctx)
+
+	var sessions []models.FocusSession
+	if err := db.Where("end_time IS NULL").Find(&sessions).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	staleAfter := FocusStaleAfter()
+	for _, session := range sessions {
+		planned := time.Duration(session.PlannedDuration) * time.Minute
+		plannedEnd := session.StartTime.Add(planned)
+		if now.Before(plannedEnd.Add(staleAfter)) {
+			continue
+		}
+
+		notes := strings.TrimSpace(session.Notes + "\nClosed automatically")
+		if err := db.Model(&session).Updates(map[string]interface{}{
+			"end_time":        plannedEnd,
+			"actual_duration": session.PlannedDuration,
+			"completed":       false,
+			"notes":           notes,
+		}).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/rayzox/tickr-backend/database"
+	"github.com/rayzox/tickr-backend/models"
+)
+
+// RolloverHabits starts a new day for habits whose local midnight has passed:
+// CompletedToday is cleared, and the streak is broken if the habit wasn't done
+// within its frequency. It's safe to run at any time, only days that actually
+// ended are rolled over.
+func RolloverHabits(ctx context.Context) error {
+	db := database.DB.WithContext(ctx)
+
+	var habits []models.Habit
+	if err := db.Where("completed_today = ? OR streak > 0", true).Find(&habits).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, habit := range habits {
+		loc := HabitLocation(habit)
+		today := startOfDay(now.In(loc))
+
+		// Never completed, as far as we know, so there's nothing to keep
+		lastDay := time.Time{}
+		if habit.LastCompletedAt != nil {
+			lastDay = startOfDay(habit.LastCompletedAt.In(loc))
+		}
+
+		updates := map[string]interface{}{}
+		if habit.CompletedToday && lastDay.Before(today) {
+			updates["completed_today"] = false
+		}
+		if habit.Streak > 0 && lastDay.Before(streakDeadline(habit.Frequency, today)) {
+			updates["streak"] = 0
+		}
+
+		if len(updates) > 0 {
+			if err := db.Model(&habit).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// HabitLocation is the timezone the habit's days are counted in
+func HabitLocation(habit models.Habit) *time.Location {
+	return location(habit.Timezone)
+}
+
+// location loads an IANA timezone, falling back to the server's
+func location(name string) *time.Location {
+	if name != "" {
+		if loc, err := time.LoadLocation(name); err == nil {
+			return loc
+		}
+	}
+	return time.Local
+}
+
+// streakDeadline is the earliest day the habit must have last been done on to keep its streak
+func streakDeadline(frequency string, today time.Time) time.Time {
+	switch frequency {
+	case "weekly":
+		return today.AddDate(0, 0, -7)
+	case "monthly":
+		return today.AddDate(0, -1, 0)
+	default:
+		return today.AddDate(0, 0, -1)
+	}
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,31 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/rayzox/tickr-backend/trash"
+)
+
+// Default returns a scheduler with all of Tickr's jobs registered
+func Default() *Scheduler {
+	s := NewScheduler()
+	s.Register(Job{Name: "reminders", Every: time.Minute, Run: DispatchReminders})
+	s.Register(Job{Name: "habit_rollover", Every: 5 * time.Minute, Run: RolloverHabits})
+	s.Register(Job{Name: "focus_autoclose", Every: 10 * time.Minute, Run: CloseStaleFocusSessions})
+	s.Register(Job{Name: "overdue_tasks", Every: 15 * time.Minute, Run: FlagOverdueTasks})
+	s.Register(Job{Name: "recurring_tasks", Every: 15 * time.Minute, Run: GenerateRecurringTasks})
+	s.Register(Job{Name: "digests", Every: 5 * time.Minute, Run: SendDigests})
+	s.Register(Job{Name: "trash_purge", Every: time.Hour, Run: purgeTrash})
+	return s
+}
+
+// purgeTrash removes items that have been in the trash longer than the retention period
+func purgeTrash(ctx context.Context) error {
+	purged, err := trash.PurgeExpired(ctx)
+	if err == nil && purged > 0 {
+		slog.Info("Purged expired trash", "items", purged)
+	}
+	return err
+}
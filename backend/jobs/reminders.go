@@ -0,0 +1,171 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rayzox/tickr-backend/database"
+	"github.com/rayzox/tickr-backend/models"
+	"github.com/rayzox/tickr-backend/notify"
+	"gorm.io/gorm"
+)
+
+// missedReminderWindow is how late a reminder may still fire, e.g. after the
+// server was down. Older ones are skipped rather than all going off at once.
+const missedReminderWindow = time.Hour
+
+// dueReminder is the occurrence a reminder fires for and what to tell the user
+type dueReminder struct {
+	occurrence time.Time
+	fireAt     time.Time
+	title      string
+	body       string
+}
+
+// DispatchReminders turns reminders whose time has come into notifications and
+// pushes new and un-snoozed notifications to the stream
+func DispatchReminders(ctx context.Context) error {
+	db := database.DB.WithContext(ctx)
+
+	var reminders []models.Reminder
+	if err := db.Find(&reminders).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, reminder := range reminders {
+		due, ok, err := reminderDue(db, reminder, now)
+		if err != nil {
+			return err
+		}
+		if !ok || now.Before(due.fireAt) || now.Sub(due.fireAt) > missedReminderWindow {
+			continue
+		}
+		if reminder.FiredFor != nil && reminder.FiredFor.Equal(due.occurrence) {
+			continue
+		}
+
+		notification := models.Notification{
+			ReminderID: &reminder.ID,
+			Entity:     reminder.Entity,
+			EntityID:   reminder.EntityID,
+			Title:      due.title,
+			Body:       due.body,
+			DeliverAt:  now,
+		}
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&notification).Error; err != nil {
+				return err
+			}
+			return tx.Model(&reminder).Update("fired_for", due.occurrence).Error
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return pushNotifications(db, now)
+}
+
+// reminderDue works out the occurrence the reminder is for, false when there's
+// nothing to remind about (task done, habit already checked, entity deleted)
+func reminderDue(db *gorm.DB, reminder models.Reminder, now time.Time) (dueReminder, bool, error) {
+	offset := time.Duration(reminder.OffsetMinutes) * time.Minute
+	due := dueReminder{title: reminder.Title, body: "Reminder"}
+
+	if reminder.RemindAt != nil {
+		due.occurrence, due.fireAt = *reminder.RemindAt, *reminder.RemindAt
+		if reminder.Entity == "" {
+			return due, true, nil
+		}
+	}
+
+	var title string
+	var err error
+	switch reminder.Entity {
+	case "task":
+		var task models.Task
+		if err = db.First(&task, reminder.EntityID).Error; err == nil {
+			if task.Completed || task.DueDate.IsZero() {
+				return due, false, nil
+			}
+			title = task.Title
+			if reminder.RemindAt == nil {
+				due.occurrence = task.DueDate
+				due.body = "Due " + task.DueDate.Format("Mon Jan 2 15:04")
+			}
+		}
+	case "event":
+		var event models.Event
+		if err = db.First(&event, reminder.EntityID).Error; err == nil {
+			title = event.Title
+			if reminder.RemindAt == nil {
+				due.occurrence = event.EventDate
+				due.body = "Starts " + event.EventDate.Format("Mon Jan 2 15:04")
+			}
+		}
+	case "habit":
+		var habit models.Habit
+		if err = db.First(&habit, reminder.EntityID).Error; err == nil {
+			if habit.CompletedToday {
+				return due, false, nil
+			}
+			title = habit.Name
+			if reminder.RemindAt == nil {
+				due.occurrence = habitOccurrence(habit, offset, now)
+				due.body = "Time for your habit"
+			}
+		}
+	default:
+		return due, false, nil
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return due, false, nil
+	}
+	if err != nil {
+		return due, false, err
+	}
+
+	if due.title == "" {
+		due.title = title
+	}
+	if reminder.RemindAt == nil {
+		due.fireAt = due.occurrence.Add(-offset)
+	}
+	return due, true, nil
+}
+
+// habitOccurrence is the latest target time (today's or tomorrow's, in the
+// habit's timezone) whose reminder is due by now
+func habitOccurrence(habit models.Habit, offset time.Duration, now time.Time) time.Time {
+	loc := HabitLocation(habit)
+	target, err := time.Parse("15:04", habit.TargetTime)
+	if err != nil {
+		target, _ = time.Parse("15:04", "09:00")
+	}
+
+	today := startOfDay(now.In(loc))
+	occurrence := time.Date(today.Year(), today.Month(), today.Day(), target.Hour(), target.Minute(), 0, 0, loc)
+	if tomorrow := occurrence.AddDate(0, 0, 1); !now.Before(tomorrow.Add(-offset)) {
+		return tomorrow
+	}
+	return occurrence
+}
+
+// pushNotifications sends notifications that became visible since they were last pushed
+func pushNotifications(db *gorm.DB, now time.Time) error {
+	var pending []models.Notification
+	if err := db.Where("deliver_at <= ? AND read_at IS NULL AND (pushed_at IS NULL OR pushed_at < deliver_at)", now).
+		Order("deliver_at").Find(&pending).Error; err != nil {
+		return err
+	}
+
+	for _, notification := range pending {
+		if err := db.Model(&notification).Update("pushed_at", now).Error; err != nil {
+			return err
+		}
+		notify.Publish(notification)
+	}
+	return nil
+}
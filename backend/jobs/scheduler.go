@@ -0,0 +1,100 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/rayzox/tickr-backend/database"
+	"github.com/rayzox/tickr-backend/metrics"
+	"github.com/rayzox/tickr-backend/models"
+	"gorm.io/gorm/clause"
+)
+
+// Job is a task the server runs on a fixed interval
+type Job struct {
+	Name  string
+	Every time.Duration
+	Run   func(ctx context.Context) error
+}
+
+// Scheduler runs registered jobs in the background until its context is
+// cancelled. A job never overlaps with itself, and one that was due while the
+// server was down runs right after Start.
+type Scheduler struct {
+	jobs []Job
+	wg   sync.WaitGroup
+}
+
+func NewScheduler() *Scheduler {
+	return &Scheduler{}
+}
+
+func (s *Scheduler) Register(job Job) {
+	s.jobs = append(s.jobs, job)
+}
+
+// Start launches every job in its own goroutine
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go func(job Job) {
+			defer s.wg.Done()
+			s.loop(ctx, job)
+		}(job)
+	}
+}
+
+// Wait blocks until every job has stopped, letting runs in progress finish
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	timer := time.NewTimer(untilDue(job))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		run(ctx, job)
+		timer.Reset(job.Every)
+	}
+}
+
+// untilDue is how long to wait before the first run, based on the last one on record
+func untilDue(job Job) time.Duration {
+	var last models.JobRun
+	result := database.DB.Where("name = ?", job.Name).Limit(1).Find(&last)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return 0 // never ran
+	}
+	if wait := time.Until(last.LastRunAt.Add(job.Every)); wait > 0 {
+		return wait
+	}
+	return 0 // missed while the server was down
+}
+
+func run(ctx context.Context, job Job) {
+	started := time.Now()
+	err := job.Run(ctx)
+
+	record := models.JobRun{Name: job.Name, LastRunAt: started, Duration: time.Since(started).Milliseconds()}
+	metrics.JobDuration.Observe(time.Since(started).Seconds(), job.Name)
+	if err != nil {
+		record.LastError = err.Error()
+		slog.Error("Job failed", "job", job.Name, "error", err)
+		metrics.JobRuns.Inc(job.Name, "failure")
+	} else {
+		metrics.JobRuns.Inc(job.Name, "success")
+	}
+
+	if err := database.DB.Clauses(clause.OnConflict{UpdateAll: true}).Create(&record).Error; err != nil {
+		slog.Error("Failed to record job run", "job", job.Name, "error", err)
+	}
+}
@@ -0,0 +1,98 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/rayzox/tickr-backend/database"
+	"github.com/rayzox/tickr-backend/models"
+	"gorm.io/gorm"
+)
+
+// FlagOverdueTasks keeps Task.Overdue in line with the due dates
+func FlagOverdueTasks(ctx context.Context) error {
+	db := database.DB.WithContext(ctx)
+
+	var tasks []models.Task
+	if err := db.Where("completed = ? OR overdue = ?", false, true).Find(&tasks).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, task := range tasks {
+		overdue := !task.Completed && !task.DueDate.IsZero() && task.DueDate.Before(now)
+		if overdue == task.Overdue {
+			continue
+		}
+		if err := db.Model(&task).Update("overdue", overdue).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GenerateRecurringTasks creates the next occurrence of every completed
+// recurring task. The recurrence moves over to the new task, so each
+// occurrence is only repeated once.
+func GenerateRecurringTasks(ctx context.Context) error {
+	db := database.DB.WithContext(ctx)
+
+	var tasks []models.Task
+	if err := db.Where("completed = ? AND recurrence <> ''", true).Find(&tasks).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, task := range tasks {
+		due, ok := nextOccurrence(task.DueDate, task.Recurrence, now)
+		if !ok {
+			continue
+		}
+
+		next := models.Task{
+			Title:              task.Title,
+			DueDate:            due,
+			Priority:           task.Priority,
+			Description:        task.Description,
+			EstimatedPomodoros: task.EstimatedPomodoros,
+			Tags:               task.Tags,
+			Recurrence:         task.Recurrence,
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&next).Error; err != nil {
+				return err
+			}
+			return tx.Model(&task).Update("recurrence", "").Error
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// nextOccurrence steps from the last due date until it's in the future, so a
+// task completed late doesn't spawn one that's already overdue
+func nextOccurrence(due time.Time, recurrence string, now time.Time) (time.Time, bool) {
+	var step func(time.Time) time.Time
+	switch recurrence {
+	case "daily":
+		step = func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }
+	case "weekly":
+		step = func(t time.Time) time.Time { return t.AddDate(0, 0, 7) }
+	case "monthly":
+		step = func(t time.Time) time.Time { return t.AddDate(0, 1, 0) }
+	default:
+		return time.Time{}, false
+	}
+
+	if due.IsZero() {
+		return step(now), true
+	}
+	for due = step(due); !due.After(now); due = step(due) {
+	}
+	return due, true
+}
@@ -0,0 +1,73 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// SlowQuery is how long a query runs before it's logged as a warning
+const SlowQuery = 200 * time.Millisecond
+
+// Gorm sends gorm's logs to slog. Failed queries are errors, with the SQL and
+// the request ID of the context they ran with (database.DB.WithContext), slow
+// ones warnings, and at debug level every query is logged. Record not found
+// and constraint violations are expected, handlers answer them with a 404 or
+// 409, so the first isn't logged and the others only as warnings.
+func Gorm() gormlogger.Interface {
+	return gormLogger{}
+}
+
+type gormLogger struct{}
+
+// LogMode is gorm's own level, slog's decides instead
+func (l gormLogger) LogMode(gormlogger.LogLevel) gormlogger.Interface {
+	return l
+}
+
+func (gormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	slog.InfoContext(ctx, fmt.Sprintf(msg, args...), "source", "gorm")
+}
+
+func (gormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	slog.WarnContext(ctx, fmt.Sprintf(msg, args...), "source", "gorm")
+}
+
+func (gormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	slog.ErrorContext(ctx, fmt.Sprintf(msg, args...), "source", "gorm")
+}
+
+func (gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	elapsed := time.Since(begin)
+	failed := err != nil && !errors.Is(err, gorm.ErrRecordNotFound)
+
+	level := slog.LevelDebug
+	msg := "query"
+	switch {
+	case failed && (errors.Is(err, gorm.ErrDuplicatedKey) || errors.Is(err, gorm.ErrForeignKeyViolated) || errors.Is(err, gorm.ErrCheckConstraintViolated)):
+		level, msg = slog.LevelWarn, "query rejected"
+	case failed:
+		level, msg = slog.LevelError, "query failed"
+	case elapsed >= SlowQuery:
+		level, msg = slog.LevelWarn, "slow query"
+	}
+	if !slog.Default().Enabled(ctx, level) {
+		return
+	}
+
+	sql, rows := fc()
+	attrs := []slog.Attr{
+		slog.String("sql", sql),
+		slog.Int64("rows", rows),
+		slog.Float64("elapsed_ms", float64(elapsed.Microseconds())/1000),
+	}
+	if failed {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	slog.LogAttrs(ctx, level, msg, attrs...)
+}
@@ -1,32 +1,117 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/rayzox/tickr-backend/config"
 	"github.com/rayzox/tickr-backend/database"
-	"github.com/rayzox/tickr-backend/models"
+	"github.com/rayzox/tickr-backend/jobs"
+	"github.com/rayzox/tickr-backend/logging"
+	"github.com/rayzox/tickr-backend/metrics"
+	"github.com/rayzox/tickr-backend/notify"
 	"github.com/rayzox/tickr-backend/routes"
+	"github.com/rayzox/tickr-backend/web"
+	"github.com/rayzox/tickr-backend/webhooks"
 
-	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
-	r := gin.Default()
-	r.Use(cors.Default())
+	// defaults < config file < TICKR_* env < flags, see tickr -h
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fatal("Invalid configuration", err)
+	}
+	config.Set(cfg)
+	if err := logging.Setup(os.Stderr, cfg.Log.Level, cfg.Log.Format); err != nil {
+		fatal("Invalid logging setup", err)
+	}
+
+	r := gin.New()
+	r.Use(logging.Middleware("/healthz", "/readyz", "/metrics"), metrics.Middleware(), logging.Recovery())
+	r.Use(routes.SecurityHeaders(), routes.CORS())
+	if err := r.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		fatal("Invalid trusted proxies", err)
+	}
 
 	// connect DB & migrate
-	database.ConnectDatabase()
-	database.DB.AutoMigrate(&models.Task{})
-	database.DB.AutoMigrate(&models.Habit{})
-	database.DB.AutoMigrate(&models.Event{}) // Your existing event model - now enhanced
-	database.DB.AutoMigrate(&models.PomodoroSession{})
-	database.DB.AutoMigrate(&models.FocusSession{}) // New focus model
-
-	// register routes
-	routes.RegisterTaskRoutes(r)
-	routes.RegisterHabitRoutes(r)
-	routes.RegisterPomodoroRoutes(r)
-	routes.RegisterCalendarRoutes(r)     // New calendar routes
-	routes.RegisterProductivityRoutes(r) // New productivity routes
-
-	r.Run(":8080")
+	database.ConnectDatabase(cfg.Database)
+	if err := database.Migrate(); err != nil {
+		fatal("Failed to migrate database", err)
+	}
+
+	// register routes, under /api/v1 and at the deprecated legacy paths
+	routes.RegisterAPIRoutes(r)
+	// everything else is the frontend, built into the binary
+	r.NoRoute(web.Handler(), routes.NotFound)
+
+	// stop on Ctrl+C / SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Jobs get their own context, they're stopped only once requests drained
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	scheduler := jobs.Default()
+	scheduler.Start(jobsCtx)
+	webhooksDone := webhooks.Start(jobsCtx)
+
+	srv := &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           r,
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      60 * time.Second, // lifted for the notification stream
+		IdleTimeout:       2 * time.Minute,
+	}
+	serveErr := make(chan error, 1)
+	go func() {
+		slog.Info("Listening", "addr", cfg.Addr)
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		fatal("Server failed", err)
+	case <-ctx.Done():
+	}
+	stop() // a second Ctrl+C kills right away
+
+	// Fail /readyz and keep serving for a while, until the load balancer has
+	// noticed and stopped sending requests. Then stop taking them and let the
+	// ones in flight finish, streams end on their own once notify is closed.
+	slog.Info("Shutting down", "drain_delay", cfg.DrainDelay.Duration)
+	routes.Drain()
+	time.Sleep(cfg.DrainDelay.Duration)
+	notify.Close()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout.Duration)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Error("Server shutdown", "error", err)
+	}
+
+	// Then the background work, runs in progress finish first
+	stopJobs()
+	scheduler.Wait()
+	<-webhooksDone
+
+	if err := database.Close(); err != nil {
+		slog.Error("Failed to close database", "error", err)
+	}
+	slog.Info("Stopped")
+}
+
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
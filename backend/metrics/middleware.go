@@ -0,0 +1,24 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Middleware counts requests and times them by route. Requests no route
+// matched, like the frontend's, are counted as "unmatched".
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		HTTPRequests.Inc(c.Request.Method, route, strconv.Itoa(c.Writer.Status()))
+		HTTPDuration.Observe(time.Since(start).Seconds(), c.Request.Method, route)
+	}
+}
@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"runtime"
+	"time"
+)
+
+// The server's metrics. Routes are gin patterns like /api/v1/tasks/:id, so
+// the number of series stays bounded.
+var (
+	HTTPRequests = NewCounter("tickr_http_requests_total",
+		"HTTP requests handled, by route and status.", "method", "route", "status")
+	HTTPDuration = NewHistogram("tickr_http_request_duration_seconds",
+		"Time to handle an HTTP request, by route.", DefaultBuckets, "method", "route")
+
+	DBQueryDuration = NewHistogram("tickr_db_query_duration_seconds",
+		"Time a database query took, by operation (create, query, update, delete, row, raw) and table.", DefaultBuckets, "operation", "table")
+	DBQueryErrors = NewCounter("tickr_db_query_errors_total",
+		"Database queries that failed, not counting record not found.", "operation", "table")
+
+	PomodorosCompleted = NewCounter("tickr_pomodoros_completed_total",
+		"Pomodoros recorded, by phase.", "phase")
+
+	JobRuns = NewCounter("tickr_job_runs_total",
+		"Background job runs, by outcome (success or failure).", "job", "outcome")
+	JobDuration = NewHistogram("tickr_job_duration_seconds",
+		"Time a background job run took.", []float64{0.01, 0.1, 0.5, 1, 5, 10, 30, 60, 300}, "job")
+
+	WebhookAttempts = NewCounter("tickr_webhook_delivery_attempts_total",
+		"Webhook delivery attempts, by outcome: succeeded, retrying, or failed when it was the last attempt.", "outcome")
+)
+
+var started = time.Now()
+
+func init() {
+	NewGaugeFunc("go_goroutines", "Number of goroutines that currently exist.", func() float64 {
+		return float64(runtime.NumGoroutine())
+	})
+	NewGaugeFunc("process_start_time_seconds", "Start time of the process since unix epoch in seconds.", func() float64 {
+		return float64(started.Unix())
+	})
+}
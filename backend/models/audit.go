@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// AuditLog is an append-only record of a change made to a task, habit, event or session
+type AuditLog struct {
+	ID         uint                   `json:"id" gorm:"primarykey"`
+	CreatedAt  time.Time              `json:"created_at"`
+	EntityType string                 `json:"entity_type" gorm:"index:idx_audit_entity"` // 'task', 'habit', 'event', 'pomodoro', 'focus'
+	EntityID   uint                   `json:"entity_id" gorm:"index:idx_audit_entity"`
+	Action     string                 `json:"action"` // 'create', 'update', 'delete', 'restore', 'purge'
+	Changes    map[string]FieldChange `json:"changes" gorm:"serializer:json"`
+
+	// Who made the change and from where
+	Actor     string `json:"actor"`
+	Client    string `json:"client"`
+	IPAddress string `json:"ip_address"`
+}
+
+type FieldChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
@@ -0,0 +1,23 @@
+package models
+
+// BulkOperation applies one action to a set of rows, e.g.
+// {"action": "move", "ids": [1, 2], "days": 3}
+type BulkOperation struct {
+	Action     string `json:"action" binding:"required"` // 'complete', 'delete', 'priority', 'move', 'add_tag'
+	IDs        []uint `json:"ids" binding:"required"`
+	Priority   string `json:"priority"`    // for 'priority'
+	Days       int    `json:"days"`        // for 'move', may be negative
+	Tag        string `json:"tag"`         // for 'add_tag'
+	WithEvents bool   `json:"with_events"` // for task 'delete', trash the scheduled events too
+}
+
+type BulkRequest struct {
+	Operations []BulkOperation `json:"operations" binding:"required"`
+}
+
+type BulkResult struct {
+	ID     uint   `json:"id"`
+	Action string `json:"action"`
+	Status string `json:"status"` // 'ok' or 'error'
+	Error  string `json:"error,omitempty"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// Change is one entry of the change feed behind /sync. IDs only ever grow, so
+// the ID of the last change a client has seen is its sync cursor.
+type Change struct {
+	ID        uint      `json:"cursor" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	Entity    string    `json:"entity" gorm:"index:idx_change_entity"` // 'task', 'habit', 'event', 'pomodoro', 'focus'
+	EntityID  uint      `json:"entity_id" gorm:"index:idx_change_entity"`
+	Operation string    `json:"operation"` // 'create', 'update', 'delete'
+}
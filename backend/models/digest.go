@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DigestSubscription opts an address in to the daily and/or weekly digest emails
+type DigestSubscription struct {
+	gorm.Model
+	User     string `json:"user" gorm:"index"` // X-Tickr-User of whoever subscribed
+	Email    string `json:"email"`
+	Daily    bool   `json:"daily"`
+	Weekly   bool   `json:"weekly"`    // sent on Sundays
+	SendTime string `json:"send_time"` // "HH:MM" in the subscription's timezone, 07:00 by default
+	Timezone string `json:"timezone"`  // IANA name, empty for the server's
+
+	LastDailySentAt  *time.Time `json:"last_daily_sent_at"`
+	LastWeeklySentAt *time.Time `json:"last_weekly_sent_at"`
+}
+
+// DigestSubscriptionRequest creates or replaces a subscription, the rest of
+// it is the server's to set
+type DigestSubscriptionRequest struct {
+	Email    string `json:"email" binding:"required"`
+	Daily    bool   `json:"daily"`
+	Weekly   bool   `json:"weekly"`
+	SendTime string `json:"send_time"`
+	Timezone string `json:"timezone"`
+}
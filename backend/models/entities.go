@@ -0,0 +1,11 @@
+package models
+
+// Entities maps the entity names used across the API (trash types, undo
+// steps, audit and sync records) to a constructor for their model
+var Entities = map[string]func() interface{}{
+	"task":     func() interface{} { return &Task{} },
+	"habit":    func() interface{} { return &Habit{} },
+	"event":    func() interface{} { return &Event{} },
+	"pomodoro": func() interface{} { return &PomodoroSession{} },
+	"focus":    func() interface{} { return &FocusSession{} },
+}
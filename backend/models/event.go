@@ -17,11 +17,12 @@ type Event struct {
 	Priority  string    `json:"priority"`   // 'low', 'medium', 'high'
 	AllDay    bool      `json:"all_day"`
 	Duration  int       `json:"duration"` // Duration in minutes
+	Tags      string    `json:"tags"`     // Comma-separated
 
 	// Relationships
 	EventType string `json:"event_type"` // 'task', 'habit', 'pomodoro', 'custom'
 	TaskID    *uint  `json:"task_id"`
 	HabitID   *uint  `json:"habit_id"`
-	Task      *Task  `json:"task,omitempty" gorm:"foreignKey:TaskID"`
-	Habit     *Habit `json:"habit,omitempty" gorm:"foreignKey:HabitID"`
+	Task      *Task  `json:"task,omitempty" gorm:"foreignKey:TaskID;constraint:OnDelete:SET NULL"`
+	Habit     *Habit `json:"habit,omitempty" gorm:"foreignKey:HabitID;constraint:OnDelete:CASCADE"`
 }
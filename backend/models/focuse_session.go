@@ -9,7 +9,7 @@ import (
 type FocusSession struct {
 	gorm.Model
 	TaskID          uint       `json:"task_id"`
-	Task            Task       `json:"task" gorm:"foreignKey:TaskID"`
+	Task            Task       `json:"task" gorm:"foreignKey:TaskID;constraint:OnDelete:CASCADE"`
 	StartTime       time.Time  `json:"start_time"`
 	EndTime         *time.Time `json:"end_time"`
 	PlannedDuration int        `json:"planned_duration"`
@@ -17,3 +17,14 @@ type FocusSession struct {
 	Notes           string     `json:"notes"`
 	Completed       bool       `json:"completed"`
 }
+
+type FocusStartRequest struct {
+	TaskID          uint `json:"task_id" binding:"required"`
+	PlannedDuration int  `json:"planned_duration"` // minutes, the configured pomodoro length when 0
+}
+
+type FocusCompleteRequest struct {
+	Notes      string `json:"notes"`
+	Completed  bool   `json:"completed"`
+	PomodoroID *uint  `json:"pomodoro_id"` // pomodoro recorded for the session, counts towards the task
+}
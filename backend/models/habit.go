@@ -17,5 +17,6 @@ type Habit struct {
 	Color           string     `json:"color"`
 	LastCompletedAt *time.Time `json:"last_completed_at"`
 	TargetTime      string     `json:"target_time"`
-	CalendarEvents  []Event    `json:"calendar_events,omitempty" gorm:"foreignKey:HabitID"`
+	Timezone        string     `json:"timezone"` // IANA name like "Europe/Paris", days roll over at its midnight. Empty uses the server's.
+	CalendarEvents  []Event    `json:"calendar_events,omitempty" gorm:"foreignKey:HabitID;constraint:OnDelete:CASCADE"`
 }
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// IdempotencyKey remembers the response to a POST sent with an Idempotency-Key
+// header so a retried request is answered from here instead of running twice
+type IdempotencyKey struct {
+	ID          uint      `gorm:"primarykey"`
+	CreatedAt   time.Time `gorm:"index"`
+	Key         string    `gorm:"uniqueIndex:idx_idempotency_request"`
+	Method      string    `gorm:"uniqueIndex:idx_idempotency_request"`
+	Path        string    `gorm:"uniqueIndex:idx_idempotency_request"`
+	RequestHash string    // SHA-256 of the body, a key can't be reused for a different payload
+	StatusCode  int       // 0 while the original request is still being handled
+	ContentType string
+	Body        []byte
+}
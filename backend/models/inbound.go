@@ -0,0 +1,15 @@
+package models
+
+import "gorm.io/gorm"
+
+// InboundSender is an address allowed to create tasks by email
+type InboundSender struct {
+	gorm.Model
+	User    string `json:"user" gorm:"index"` // X-Tickr-User of whoever added it
+	Address string `json:"address" gorm:"uniqueIndex"`
+}
+
+// InboundSenderRequest allows an address, the rest of the sender is the server's to set
+type InboundSenderRequest struct {
+	Address string `json:"address" binding:"required"`
+}
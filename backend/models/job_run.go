@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// JobRun is the last run of a background job, kept so jobs that were due while
+// the server was down run as soon as it's back
+type JobRun struct {
+	Name      string    `json:"name" gorm:"primarykey"`
+	LastRunAt time.Time `json:"last_run_at"`
+	LastError string    `json:"last_error"`
+	Duration  int64     `json:"duration_ms"`
+}
@@ -14,11 +14,19 @@ type PomodoroSession struct {
 
 	// New integration fields
 	TaskID     *uint  `json:"task_id"`
-	Task       *Task  `json:"task,omitempty" gorm:"foreignKey:TaskID"`
+	Task       *Task  `json:"task,omitempty" gorm:"foreignKey:TaskID;constraint:OnDelete:SET NULL"`
 	Notes      string `json:"notes"`
 	Productive bool   `json:"productive"`
 }
 
+// PomodoroSettings are the default phase lengths, in minutes, set in the
+// server's config
+type PomodoroSettings struct {
+	WorkMinutes       int `json:"work_minutes"`
+	ShortBreakMinutes int `json:"short_break_minutes"`
+	LongBreakMinutes  int `json:"long_break_minutes"`
+}
+
 type PomodoroStats struct {
 	CompletedSessions int `json:"completedSessions"`
 	TotalMinutes      int `json:"totalMinutes"`
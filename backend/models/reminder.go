@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Reminder asks for a notification some time before a task is due, an event
+// starts or a habit's target time comes around (every day for habits), or at
+// a fixed time.
+type Reminder struct {
+	gorm.Model
+	Entity        string     `json:"entity" gorm:"index:idx_reminder_entity"` // 'task', 'event', 'habit', or empty with remind_at
+	EntityID      uint       `json:"entity_id" gorm:"index:idx_reminder_entity"`
+	OffsetMinutes int        `json:"offset_minutes"` // how long before the due date / start
+	RemindAt      *time.Time `json:"remind_at"`      // fixed time, takes over from the offset
+	Title         string     `json:"title"`          // shown instead of the entity's title when set
+	FiredFor      *time.Time `json:"fired_for"`      // occurrence the reminder last fired for
+}
+
+// Notification is an entry in the notifications inbox
+type Notification struct {
+	gorm.Model
+	ReminderID *uint      `json:"reminder_id"`
+	Entity     string     `json:"entity"`
+	EntityID   uint       `json:"entity_id"`
+	Title      string     `json:"title"`
+	Body       string     `json:"body"`
+	DeliverAt  time.Time  `json:"deliver_at" gorm:"index"` // hidden from the inbox until then, pushed back when snoozed
+	PushedAt   *time.Time `json:"-"`                       // last time it went out on the stream
+	ReadAt     *time.Time `json:"read_at"`
+}
+
+// SnoozeRequest is the body of POST /notifications/:id/snooze, 0 minutes means 10
+type SnoozeRequest struct {
+	Minutes int `json:"minutes"`
+}
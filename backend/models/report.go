@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// Dashboard is everything planned or done on one day
+type Dashboard struct {
+	TodayTasks     []Task            `json:"today_tasks"`
+	TodayHabits    []Habit           `json:"today_habits"`
+	TodayEvents    []Event           `json:"today_events"`
+	TodayPomodoros []PomodoroSession `json:"today_pomodoros"`
+	ActiveFocus    FocusSession      `json:"active_focus"`
+	Date           time.Time         `json:"date"`
+}
+
+type DayStats struct {
+	Date              string `json:"date"`
+	DayName           string `json:"day_name"`
+	CompletedTasks    int64  `json:"completed_tasks"`
+	TotalTasks        int64  `json:"total_tasks"`
+	CompletedHabits   int64  `json:"completed_habits"`
+	TotalHabits       int64  `json:"total_habits"`
+	PomodoroSessions  int64  `json:"pomodoro_sessions"`
+	ProductiveMinutes int64  `json:"productive_minutes"`
+}
+
+// Weekly is the day by day recap of the week, Sunday to Saturday
+type Weekly struct {
+	WeekStart  string     `json:"week_start"`
+	DailyStats []DayStats `json:"daily_stats"`
+}
+
+// Totals adds up the week
+func (w *Weekly) Totals() DayStats {
+	var total DayStats
+	for _, day := range w.DailyStats {
+		total.CompletedTasks += day.CompletedTasks
+		total.TotalTasks += day.TotalTasks
+		total.CompletedHabits += day.CompletedHabits
+		total.PomodoroSessions += day.PomodoroSessions
+		total.ProductiveMinutes += day.ProductiveMinutes
+	}
+	return total
+}
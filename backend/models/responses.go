@@ -0,0 +1,120 @@
+package models
+
+import "time"
+
+// Responses that aren't a stored record, shared by the handlers, the API
+// client and the OpenAPI document
+
+// Message answers deletes and other actions without a record to return.
+// Destructive ones carry a token for POST /undo/:token.
+type Message struct {
+	Message       string     `json:"message"`
+	UndoToken     string     `json:"undo_token,omitempty"`
+	UndoExpiresAt *time.Time `json:"undo_expires_at,omitempty"`
+}
+
+// BulkResponse has one result per ID of every operation, the undo token reverts the whole batch
+type BulkResponse struct {
+	Results       []BulkResult `json:"results"`
+	Applied       int          `json:"applied"`
+	UndoToken     string       `json:"undo_token,omitempty"`
+	UndoExpiresAt *time.Time   `json:"undo_expires_at,omitempty"`
+}
+
+type UndoResult struct {
+	Message   string `json:"message"`
+	Operation string `json:"operation"` // e.g. 'task.delete'
+	Reverted  int    `json:"reverted"`  // number of rows put back
+}
+
+type RestoreResult struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+	ID      uint   `json:"id"`
+}
+
+type PurgeResult struct {
+	Message string `json:"message"`
+	Purged  int64  `json:"purged"`
+}
+
+type NotificationInbox struct {
+	Notifications []Notification `json:"notifications"`
+	Unread        int64          `json:"unread"` // across the whole inbox, not just this page
+}
+
+type MarkReadResult struct {
+	Message string `json:"message"`
+	Updated int64  `json:"updated"`
+}
+
+// WebhookCreated is the only response with the webhook's secret, it can't
+// be read back later
+type WebhookCreated struct {
+	Webhook
+	Secret string `json:"secret"`
+}
+
+type WebhookList struct {
+	Webhooks   []Webhook `json:"webhooks"`
+	EventTypes []string  `json:"event_types"` // what Webhook.Events can subscribe to
+}
+
+type DigestSent struct {
+	Message string `json:"message"`
+	Email   string `json:"email"`
+	Kind    string `json:"kind"`
+}
+
+// Health answers the /healthz and /readyz probes. Checks has "ok" or what's
+// wrong for each thing readiness depends on.
+type Health struct {
+	Status string            `json:"status"` // 'ok' or 'unavailable'
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// Envelope wraps every /api/v1 response. Lists come with Meta.
+type Envelope struct {
+	Data    interface{} `json:"data"`
+	Message string      `json:"message,omitempty"`
+	Meta    *Meta       `json:"meta,omitempty"`
+}
+
+// Meta describes the page of a list, pages start at 1
+type Meta struct {
+	Page       int `json:"page"`
+	PerPage    int `json:"per_page"`
+	Total      int `json:"total"`
+	TotalPages int `json:"total_pages"`
+}
+
+// ErrorEnvelope is the body of every /api/v1 error
+type ErrorEnvelope struct {
+	Error APIError `json:"error"`
+}
+
+type APIError struct {
+	Code    ErrorCode `json:"code"`
+	Message string    `json:"message"`
+	// Anything else the error came with, e.g. the per-item results of a failed bulk request
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// ErrorCode tells clients what went wrong without parsing messages
+type ErrorCode string
+
+const (
+	CodeBadRequest         ErrorCode = "bad_request"
+	CodeValidationFailed   ErrorCode = "validation_failed"
+	CodeUnauthorized       ErrorCode = "unauthorized"
+	CodeForbidden          ErrorCode = "forbidden"
+	CodeNotFound           ErrorCode = "not_found"
+	CodeConflict           ErrorCode = "conflict"
+	CodePreconditionFailed ErrorCode = "precondition_failed"
+	CodePayloadTooLarge    ErrorCode = "payload_too_large"
+	CodeUnprocessable      ErrorCode = "unprocessable"
+	CodeRateLimited        ErrorCode = "rate_limited"
+	CodeInternal           ErrorCode = "internal_error"
+	CodeUpstreamFailed     ErrorCode = "upstream_failed"
+	CodeUnavailable        ErrorCode = "unavailable"
+)
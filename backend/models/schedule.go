@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// ScheduleTaskRequest puts a task on the calendar, see POST /productivity/schedule/task
+type ScheduleTaskRequest struct {
+	TaskID    uint      `json:"task_id" binding:"required"`
+	EventDate time.Time `json:"event_date" binding:"required"`
+	Duration  int       `json:"duration"`
+}
+
+// ScheduleHabitRequest adds a habit's occurrences over the next Days days, see POST /productivity/schedule/habit
+type ScheduleHabitRequest struct {
+	HabitID   uint      `json:"habit_id" binding:"required"`
+	StartDate time.Time `json:"start_date" binding:"required"`
+	Days      int       `json:"days"`
+}
+
+type ScheduleHabitResult struct {
+	ScheduledEvents int     `json:"scheduled_events"`
+	Created         int     `json:"created"`
+	Updated         int     `json:"updated"`
+	Skipped         int     `json:"skipped"` // already on the calendar at the target time
+	Events          []Event `json:"events"`
+}
@@ -0,0 +1,49 @@
+package models
+
+// SyncRecord is the latest state of one entity in a pull. Deleted records are
+// tombstones and carry no data.
+type SyncRecord struct {
+	Entity  string      `json:"entity"`
+	ID      uint        `json:"id"`
+	Cursor  uint        `json:"cursor"`
+	Deleted bool        `json:"deleted"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// SyncEdit is one offline edit pushed by a client
+type SyncEdit struct {
+	ClientID   string                 `json:"client_id"` // echoed back so clients can map temporary IDs
+	Entity     string                 `json:"entity" binding:"required"`
+	ID         uint                   `json:"id"`                    // 0 for creates
+	Operation  string                 `json:"op" binding:"required"` // 'create', 'update', 'delete'
+	BaseCursor uint                   `json:"base_cursor"`           // cursor the edit was made against
+	Data       map[string]interface{} `json:"data"`                  // full record for creates, merge patch for updates
+}
+
+type SyncPushRequest struct {
+	Changes []SyncEdit `json:"changes" binding:"required"`
+}
+
+type SyncPushResult struct {
+	ClientID string      `json:"client_id,omitempty"`
+	Entity   string      `json:"entity"`
+	ID       uint        `json:"id"`
+	Status   string      `json:"status"` // 'applied', 'conflict' or 'error'
+	Error    string      `json:"error,omitempty"`
+	Server   interface{} `json:"server,omitempty"` // current server version on conflict, nil if deleted
+
+	// Cursors are the change feed entries the edit wrote, a delete writes one
+	// per cascaded record. They aren't a pull cursor: other clients' changes
+	// can sit between them and whatever the client pulled last.
+	Cursors []uint `json:"cursors,omitempty"`
+}
+
+type SyncPullResponse struct {
+	Cursor  uint         `json:"cursor"`
+	HasMore bool         `json:"has_more"`
+	Changes []SyncRecord `json:"changes"`
+}
+
+type SyncPushResponse struct {
+	Results []SyncPushResult `json:"results"`
+}
@@ -17,8 +17,11 @@ type Task struct {
 	Description        string            `json:"description"`
 	DueDateParsed      *time.Time        `json:"due_date_parsed"`
 	CalendarEventID    *uint             `json:"calendar_event_id"`
-	CalendarEvent      *Event            `json:"calendar_event,omitempty" gorm:"foreignKey:CalendarEventID"`
-	PomodoroSessions   []PomodoroSession `json:"pomodoro_sessions,omitempty" gorm:"foreignKey:TaskID"`
+	CalendarEvent      *Event            `json:"calendar_event,omitempty" gorm:"foreignKey:CalendarEventID;constraint:OnDelete:SET NULL"`
+	PomodoroSessions   []PomodoroSession `json:"pomodoro_sessions,omitempty" gorm:"foreignKey:TaskID;constraint:OnDelete:SET NULL"`
 	EstimatedPomodoros int               `json:"estimated_pomodoros"`
 	CompletedPomodoros int               `json:"completed_pomodoros"`
+	Tags               string            `json:"tags"`       // Comma-separated
+	Overdue            bool              `json:"overdue"`    // Set by the overdue_tasks job
+	Recurrence         string            `json:"recurrence"` // '', 'daily', 'weekly', 'monthly'
 }
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// TrashItem is a flattened view of a soft-deleted record of any type
+type TrashItem struct {
+	Type      string     `json:"type"` // 'task', 'habit', 'event', 'pomodoro', 'focus'
+	ID        uint       `json:"id"`
+	Title     string     `json:"title"`
+	DeletedAt time.Time  `json:"deleted_at"`
+	PurgeAt   *time.Time `json:"purge_at"` // nil when automatic purge is disabled
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// UndoToken lets a client reverse a destructive or bulk operation for a short while
+type UndoToken struct {
+	ID        uint       `json:"-" gorm:"primarykey"`
+	CreatedAt time.Time  `json:"created_at"`
+	Token     string     `json:"token" gorm:"uniqueIndex"`
+	Operation string     `json:"operation"` // e.g. 'task.delete', 'pomodoro.clear'
+	Steps     []UndoStep `json:"steps" gorm:"serializer:json"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+}
+
+// UndoStep reverses the operation on a single row
+type UndoStep struct {
+	Entity   string                 `json:"entity"` // same names as the trash types
+	ID       uint                   `json:"id"`
+	Restore  bool                   `json:"restore,omitempty"`  // bring a soft-deleted row back
+	Previous map[string]interface{} `json:"previous,omitempty"` // column values to put back
+
+	// DeletedAt is what the operation stored in deleted_at, a restore only
+	// reverses that deletion and not one made since
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Webhook posts signed JSON payloads to URL when one of its events happens
+type Webhook struct {
+	gorm.Model
+	URL    string   `json:"url"`
+	Events []string `json:"events" gorm:"serializer:json"` // e.g. ["task.completed"], empty for all of them
+	Secret string   `json:"-"`                             // HMAC key for X-Tickr-Signature, only shown once, see WebhookCreated
+	Active bool     `json:"active"`
+}
+
+// WebhookRequest registers or replaces a webhook
+type WebhookRequest struct {
+	URL    string   `json:"url" binding:"required"`
+	Events []string `json:"events"`
+	Secret string   `json:"secret"` // generated on create when empty, kept on update when empty
+	Active *bool    `json:"active"` // active on create when left out, unchanged on update
+}
+
+// WebhookDelivery is one event sent (or being sent) to a webhook, with every attempt made
+type WebhookDelivery struct {
+	ID            uint             `json:"id" gorm:"primarykey"`
+	CreatedAt     time.Time        `json:"created_at"`
+	UpdatedAt     time.Time        `json:"updated_at"`
+	WebhookID     uint             `json:"webhook_id" gorm:"index"`
+	Event         string           `json:"event"`
+	Payload       string           `json:"payload"`                              // exact body that was signed
+	Status        string           `json:"status" gorm:"index:idx_delivery_due"` // 'pending', 'sending', 'succeeded', 'failed'
+	Attempts      []WebhookAttempt `json:"attempts" gorm:"serializer:json"`
+	NextAttemptAt *time.Time       `json:"next_attempt_at" gorm:"index:idx_delivery_due"`
+}
+
+type WebhookAttempt struct {
+	At         time.Time `json:"at"`
+	StatusCode int       `json:"status_code"` // 0 when no response came back
+	Error      string    `json:"error,omitempty"`
+	Response   string    `json:"response,omitempty"` // start of the response body
+	DurationMs int64     `json:"duration_ms"`
+}
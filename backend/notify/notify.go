@@ -0,0 +1,57 @@
+// Package notify fans out notifications to the clients listening on the stream
+package notify
+
+import "sync"
+
+var (
+	mu          sync.Mutex
+	subscribers = map[chan interface{}]struct{}{}
+	closed      bool
+)
+
+// Subscribe returns a channel receiving every published notification, and a
+// function to stop listening. The channel is closed on shutdown.
+func Subscribe() (<-chan interface{}, func()) {
+	ch := make(chan interface{}, 16)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if closed {
+		close(ch)
+		return ch, func() {}
+	}
+	subscribers[ch] = struct{}{}
+
+	return ch, func() {
+		mu.Lock()
+		defer mu.Unlock()
+		if _, ok := subscribers[ch]; ok {
+			delete(subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// Publish sends v to every subscriber. A subscriber that isn't keeping up
+// misses it rather than holding up the others, it'll see it in the inbox.
+func Publish(v interface{}) {
+	mu.Lock()
+	defer mu.Unlock()
+	for ch := range subscribers {
+		select {
+		case ch <- v:
+		default:
+		}
+	}
+}
+
+// Close ends every subscription, so open streams don't hold up a shutdown
+func Close() {
+	mu.Lock()
+	defer mu.Unlock()
+	closed = true
+	for ch := range subscribers {
+		delete(subscribers, ch)
+		close(ch)
+	}
+}
@@ -0,0 +1,9 @@
+package openapi
+
+import _ "embed"
+
+// DocsHTML is a self-contained API reference page that renders /openapi.json,
+// so the docs work without fetching anything from a CDN
+//
+//go:embed docs.html
+var DocsHTML []byte
@@ -0,0 +1,220 @@
+// Package openapi describes the API as an OpenAPI 3 document, built by
+// reflection from the request and response types each route declares, and
+// checks request bodies against it.
+package openapi
+
+import (
+	"maps"
+	"reflect"
+	"strings"
+)
+
+// Operation declares one route. Request and Response are zero values of the
+// body types, nil when there's no JSON body.
+type Operation struct {
+	Method   string
+	Path     string // gin pattern, e.g. /tasks/:id
+	Tag      string
+	Summary  string
+	Query    []Param
+	Request  interface{}
+	Response interface{}
+
+	// Content types of bodies that aren't JSON, e.g. message/rfc822
+	RequestType  string
+	ResponseType string
+}
+
+type Param struct {
+	Name        string
+	Type        string // 'string', 'integer' or 'boolean'
+	Description string
+	Required    bool
+}
+
+type Document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       Info                             `json:"info"`
+	Servers    []Server                         `json:"servers,omitempty"`
+	Paths      map[string]map[string]*operation `json:"paths"`
+	Components struct {
+		Schemas map[string]*Schema `json:"schemas"`
+	} `json:"components"`
+
+	schemas  *schemas
+	requests map[string]*Schema     // request body schema by "METHOD /gin/path"
+	params   map[string][]parameter // path parameters, same keys
+	envelope *Schema                // nil when responses aren't wrapped
+	errors   *Schema
+}
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// Server is a base URL the paths are relative to
+type Server struct {
+	URL string `json:"url"`
+}
+
+type operation struct {
+	Tags        []string             `json:"tags,omitempty"`
+	Summary     string               `json:"summary,omitempty"`
+	OperationID string               `json:"operationId"`
+	Parameters  []parameter          `json:"parameters,omitempty"`
+	RequestBody *body                `json:"requestBody,omitempty"`
+	Responses   map[string]*response `json:"responses"`
+}
+
+type parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required"`
+	Schema      *Schema `json:"schema"`
+}
+
+type body struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*mediaType `json:"content"`
+}
+
+type response struct {
+	Description string                `json:"description"`
+	Content     map[string]*mediaType `json:"content,omitempty"`
+}
+
+type mediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Error is the body of every 4xx and 5xx response
+type Error struct {
+	Error string `json:"error"`
+}
+
+func New(title, version string) *Document {
+	d := &Document{
+		OpenAPI:  "3.0.3",
+		Info:     Info{Title: title, Version: version},
+		Paths:    map[string]map[string]*operation{},
+		schemas:  &schemas{components: map[string]*Schema{}},
+		requests: map[string]*Schema{},
+		params:   map[string][]parameter{},
+	}
+	d.Components.Schemas = d.schemas.components
+	d.errors = d.schemas.schemaFor(reflect.TypeOf(Error{}))
+	return d
+}
+
+// Envelope declares the types responses come wrapped in, for operations added
+// afterwards. JSON 200 bodies become the envelope with its "data" property
+// set to the operation's response, errors are errorBody instead of Error.
+func (d *Document) Envelope(envelope, errorBody interface{}) {
+	delete(d.Components.Schemas, "Error")
+	d.envelope = d.schemas.schemaFor(reflect.TypeOf(envelope))
+	d.errors = d.schemas.schemaFor(reflect.TypeOf(errorBody))
+}
+
+// Add documents an operation. Adding the same method and path again replaces it.
+func (d *Document) Add(op Operation) {
+	path, params := openAPIPath(op.Path)
+	o := &operation{
+		Summary:     op.Summary,
+		OperationID: operationID(op.Method, op.Path),
+		Parameters:  params,
+		Responses: map[string]*response{
+			"default": {Description: "Error", Content: jsonContent(d.errors)},
+		},
+	}
+	if op.Tag != "" {
+		o.Tags = []string{op.Tag}
+	}
+	d.params[op.Method+" "+op.Path] = params
+	for _, q := range op.Query {
+		o.Parameters = append(o.Parameters, parameter{Name: q.Name, In: "query", Description: q.Description, Required: q.Required, Schema: &Schema{Type: q.Type}})
+	}
+
+	switch {
+	case op.RequestType != "":
+		o.RequestBody = &body{Required: true, Content: map[string]*mediaType{op.RequestType: {Schema: &Schema{Type: "string"}}}}
+	case op.Request != nil:
+		schema := d.schemas.schemaFor(reflect.TypeOf(op.Request))
+		o.RequestBody = &body{Required: op.Method != "PATCH", Content: jsonContent(schema)}
+		d.requests[op.Method+" "+op.Path] = schema
+	}
+
+	ok := &response{Description: "OK"}
+	switch {
+	case op.ResponseType != "":
+		ok.Content = map[string]*mediaType{op.ResponseType: {Schema: &Schema{Type: "string"}}}
+	case op.Response != nil:
+		ok.Content = jsonContent(d.wrap(d.schemas.schemaFor(reflect.TypeOf(op.Response))))
+	}
+	o.Responses["200"] = ok
+
+	if d.Paths[path] == nil {
+		d.Paths[path] = map[string]*operation{}
+	}
+	d.Paths[path][strings.ToLower(op.Method)] = o
+}
+
+// Has reports whether the route is documented
+func (d *Document) Has(method, path string) bool {
+	p, _ := openAPIPath(path)
+	_, ok := d.Paths[p][strings.ToLower(method)]
+	return ok
+}
+
+// wrap puts a response schema in the envelope's "data"
+func (d *Document) wrap(data *Schema) *Schema {
+	if d.envelope == nil {
+		return data
+	}
+	wrapped := *d.resolve(d.envelope)
+	wrapped.Properties = maps.Clone(wrapped.Properties)
+	wrapped.Properties["data"] = data
+	return &wrapped
+}
+
+// resolve follows a $ref to the component it points at
+func (d *Document) resolve(s *Schema) *Schema {
+	if s.Ref == "" {
+		return s
+	}
+	return d.Components.Schemas[strings.TrimPrefix(s.Ref, "#/components/schemas/")]
+}
+
+func jsonContent(s *Schema) map[string]*mediaType {
+	return map[string]*mediaType{"application/json": {Schema: s}}
+}
+
+// openAPIPath turns /tasks/:id into /tasks/{id} and lists its path parameters
+func openAPIPath(ginPath string) (string, []parameter) {
+	var params []parameter
+	segments := strings.Split(ginPath, "/")
+	for i, segment := range segments {
+		if segment == "" || (segment[0] != ':' && segment[0] != '*') {
+			continue
+		}
+		name := segment[1:]
+		schema := &Schema{Type: "string"}
+		if name == "id" {
+			schema = &Schema{Type: "integer"}
+		}
+		params = append(params, parameter{Name: name, In: "path", Required: true, Schema: schema})
+		segments[i] = "{" + name + "}"
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// operationID makes an ID like getTasksId from GET /tasks/:id
+func operationID(method, path string) string {
+	id := strings.ToLower(method)
+	for _, segment := range strings.FieldsFunc(path, func(r rune) bool { return r == '/' || r == '_' || r == '-' }) {
+		segment = strings.TrimLeft(segment, ":*")
+		id += strings.ToUpper(segment[:1]) + segment[1:]
+	}
+	return id
+}
@@ -0,0 +1,146 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// ValidationError says where a request body doesn't match its schema, e.g.
+// "operations[0].ids: expected array"
+type ValidationError struct {
+	Field   string
+	Problem string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Field == "" {
+		return "body: " + e.Problem
+	}
+	return e.Field + ": " + e.Problem
+}
+
+// ValidatePath checks the path parameters of a request, e.g. that :id is a
+// number, given their values by name. Undocumented routes accept anything.
+func (d *Document) ValidatePath(method, ginPath string, values map[string]string) error {
+	for _, p := range d.params[method+" "+ginPath] {
+		if p.Schema.Type != "integer" {
+			continue
+		}
+		if _, err := strconv.ParseInt(values[p.Name], 10, 64); err != nil {
+			return &ValidationError{p.Name, "expected integer in the path"}
+		}
+	}
+	return nil
+}
+
+// ValidateRequest checks a JSON body sent to the route against the schema of
+// its request type. Routes without one accept anything. PATCH bodies are merge
+// patches, so nothing in them is required and any field may be null, the
+// others mustn't have fields their type doesn't. Read-only fields like ID are
+// ignored, clients often send back whole records.
+func (d *Document) ValidateRequest(method, ginPath string, data []byte) error {
+	schema, ok := d.requests[method+" "+ginPath]
+	if !ok {
+		return nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return &ValidationError{Problem: "invalid JSON"}
+	}
+	return d.validate(schema, v, "", method == "PATCH")
+}
+
+func (d *Document) validate(s *Schema, v interface{}, field string, patch bool) error {
+	s = d.resolve(s)
+	if v == nil {
+		if s.Nullable || patch || s.Type == "" && len(s.AllOf) == 0 {
+			return nil
+		}
+		return &ValidationError{field, "must not be null"}
+	}
+	for _, sub := range s.AllOf {
+		if err := d.validate(sub, v, field, patch); err != nil {
+			return err
+		}
+	}
+
+	switch s.Type {
+	case "object":
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return &ValidationError{field, "expected object"}
+		}
+		if !patch {
+			for _, name := range s.Required {
+				if _, ok := obj[name]; !ok {
+					return &ValidationError{join(field, name), "is required"}
+				}
+			}
+		}
+		// Sorted so the same body always reports the same error
+		names := make([]string, 0, len(obj))
+		for name := range obj {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			prop := s.Properties[name]
+			if prop == nil {
+				prop = s.AdditionalProperties
+			}
+			if prop == nil && !patch {
+				return &ValidationError{join(field, name), "unknown field"}
+			}
+			if prop == nil || prop.ReadOnly {
+				continue
+			}
+			if err := d.validate(prop, obj[name], join(field, name), patch); err != nil {
+				return err
+			}
+		}
+	case "array":
+		items, ok := v.([]interface{})
+		if !ok {
+			return &ValidationError{field, "expected array"}
+		}
+		for i, item := range items {
+			if err := d.validate(s.Items, item, fmt.Sprintf("%s[%d]", field, i), patch); err != nil {
+				return err
+			}
+		}
+	case "string":
+		if _, ok := v.(string); !ok {
+			return &ValidationError{field, "expected string"}
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return &ValidationError{field, "expected boolean"}
+		}
+	case "integer":
+		n, ok := v.(json.Number)
+		if !ok {
+			return &ValidationError{field, "expected integer"}
+		}
+		if _, err := n.Int64(); err != nil {
+			return &ValidationError{field, "expected integer"}
+		}
+	case "number":
+		if _, ok := v.(json.Number); !ok {
+			return &ValidationError{field, "expected number"}
+		}
+	}
+	return nil
+}
+
+func join(field, name string) string {
+	if field == "" {
+		return name
+	}
+	return field + "." + name
+}
@@ -0,0 +1,71 @@
+// Package reports computes the summaries shown on the dashboard and in the
+// analytics view, and sent out in the email digests
+package reports
+
+import (
+	"time"
+
+	"github.com/rayzox/tickr-backend/database"
+	"github.com/rayzox/tickr-backend/models"
+)
+
+// DashboardFor builds the dashboard of the day now falls on
+func DashboardFor(now time.Time) (*models.Dashboard, error) {
+	today := now.Truncate(24 * time.Hour)
+	tomorrow := today.AddDate(0, 0, 1)
+
+	d := &models.Dashboard{Date: today}
+
+	// Get today's data
+	queries := []error{
+		database.DB.Where("due_date = ?", today.Format("2006-01-02")).Find(&d.TodayTasks).Error,
+		database.DB.Find(&d.TodayHabits).Error,
+		database.DB.Where("event_date >= ? AND event_date < ?", today, tomorrow).Find(&d.TodayEvents).Error,
+		database.DB.Where("completed_at >= ? AND completed_at < ?", today, tomorrow).Find(&d.TodayPomodoros).Error,
+	}
+	for _, err := range queries {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Get active focus session
+	database.DB.Where("end_time IS NULL").Preload("Task").Limit(1).Find(&d.ActiveFocus)
+
+	return d, nil
+}
+
+// WeeklyFor builds the recap of the week now falls in
+func WeeklyFor(now time.Time) (*models.Weekly, error) {
+	startOfWeek := now.AddDate(0, 0, -int(now.Weekday()))
+	startOfWeek = time.Date(startOfWeek.Year(), startOfWeek.Month(), startOfWeek.Day(), 0, 0, 0, 0, startOfWeek.Location())
+
+	w := &models.Weekly{WeekStart: startOfWeek.Format("2006-01-02")}
+
+	for i := 0; i < 7; i++ {
+		day := startOfWeek.AddDate(0, 0, i)
+		nextDay := day.AddDate(0, 0, 1)
+		stats := models.DayStats{Date: day.Format("2006-01-02"), DayName: day.Format("Monday")}
+
+		queries := []error{
+			database.DB.Model(&models.Task{}).Where("completed = ? AND updated_at >= ? AND updated_at < ?", true, day, nextDay).Count(&stats.CompletedTasks).Error,
+			database.DB.Model(&models.Task{}).Where("due_date = ?", day.Format("2006-01-02")).Count(&stats.TotalTasks).Error,
+
+			database.DB.Model(&models.Habit{}).Where("completed_today = ? AND updated_at >= ? AND updated_at < ?", true, day, nextDay).Count(&stats.CompletedHabits).Error,
+			database.DB.Model(&models.Habit{}).Count(&stats.TotalHabits).Error,
+
+			database.DB.Model(&models.PomodoroSession{}).Where("completed_at >= ? AND completed_at < ?", day, nextDay).Count(&stats.PomodoroSessions).Error,
+			database.DB.Model(&models.PomodoroSession{}).Where("completed_at >= ? AND completed_at < ? AND phase = ?", day, nextDay, "work").
+				Select("COALESCE(SUM(duration), 0)").Scan(&stats.ProductiveMinutes).Error,
+		}
+		for _, err := range queries {
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		w.DailyStats = append(w.DailyStats, stats)
+	}
+
+	return w, nil
+}
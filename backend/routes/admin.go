@@ -0,0 +1,52 @@
+package routes
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rayzox/tickr-backend/config"
+)
+
+// RegisterAdminRoutes serves the operator's endpoints under /admin, outside
+// /api/v1 like /metrics. They take the admin_token of the config as a bearer
+// token, and aren't there at all when it's empty.
+func RegisterAdminRoutes(r *gin.Engine) {
+	admin := r.Group("/admin", adminEnabled, requireBearer(AdminToken, "Invalid admin token"))
+	{
+		admin.GET("/config", GetConfig)
+	}
+}
+
+// AdminToken is the bearer token for /admin, admin_token in the config
+func AdminToken() string {
+	return config.Get().AdminToken
+}
+
+// adminEnabled 404s every /admin route until an admin token is set, unlike
+// /metrics they mustn't be left open by a missing setting
+func adminEnabled(c *gin.Context) {
+	if AdminToken() == "" {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Not found"})
+	}
+}
+
+// GetConfig is the configuration the server runs with, secrets redacted
+func GetConfig(c *gin.Context) {
+	c.JSON(http.StatusOK, config.Get().Redacted())
+}
+
+// requireBearer rejects requests without "Authorization: Bearer <token>",
+// unless token returns nothing
+func requireBearer(token func() string, message string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		want := token()
+		if want == "" {
+			return
+		}
+		given := c.GetHeader("Authorization")
+		if subtle.ConstantTimeCompare([]byte(given), []byte("Bearer "+want)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": message})
+		}
+	}
+}
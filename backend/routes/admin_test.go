@@ -0,0 +1,45 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rayzox/tickr-backend/config"
+)
+
+func TestAdminRoutes(t *testing.T) {
+	request := func(authorization string) int {
+		r := gin.New()
+		RegisterAdminRoutes(r)
+		req := httptest.NewRequest(http.MethodGet, "/admin/config", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		return serve(r, req).Code
+	}
+
+	t.Run("no token", func(t *testing.T) {
+		withConfig(t, func(cfg *config.Config) {})
+		if code := request(""); code != http.StatusNotFound {
+			t.Errorf("status = %d, want 404", code)
+		}
+		if code := request("Bearer "); code != http.StatusNotFound {
+			t.Errorf("empty bearer: status = %d, want 404", code)
+		}
+	})
+
+	t.Run("token", func(t *testing.T) {
+		withConfig(t, func(cfg *config.Config) { cfg.AdminToken = "s3cret" })
+		if code := request(""); code != http.StatusUnauthorized {
+			t.Errorf("without it: status = %d, want 401", code)
+		}
+		if code := request("Bearer wrong"); code != http.StatusUnauthorized {
+			t.Errorf("wrong one: status = %d, want 401", code)
+		}
+		if code := request("Bearer s3cret"); code != http.StatusOK {
+			t.Errorf("right one: status = %d, want 200", code)
+		}
+	})
+}
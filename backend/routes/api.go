@@ -0,0 +1,86 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rayzox/tickr-backend/database"
+	"github.com/rayzox/tickr-backend/models"
+	"gorm.io/gorm"
+)
+
+// APIPrefix is where the current version of the API lives
+const APIPrefix = "/api/v1"
+
+// legacySunset is when the unversioned routes go away (RFC 9745 / RFC 8594)
+const legacySunset = "Mon, 19 Apr 2027 00:00:00 GMT"
+
+// RegisterAPIRoutes mounts every resource twice: under /api/v1 with enveloped
+// responses, and at the root the way they were before versioning, marked
+// deprecated. Probes and metrics stay at the root. The OpenAPI routes go
+// last, they document the ones above.
+func RegisterAPIRoutes(r *gin.Engine) {
+	registerResources(r.Group(APIPrefix, Envelope(), RateLimit(), LimitBody(), CSRF(), ValidateRequests(), Idempotency()))
+	registerResources(r.Group("", Deprecated(), RateLimit(), LimitBody(), CSRF(), ValidateRequests(), Idempotency()))
+	RegisterHealthRoutes(r)
+	RegisterMetricsRoutes(r)
+	RegisterAdminRoutes(r)
+
+	RegisterOpenAPIRoutes(r)
+}
+
+func registerResources(r gin.IRouter) {
+	RegisterTaskRoutes(r)
+	RegisterHabitRoutes(r)
+	RegisterPomodoroRoutes(r)
+	RegisterCalendarRoutes(r)
+	RegisterProductivityRoutes(r)
+	RegisterTrashRoutes(r)
+	RegisterUndoRoutes(r)
+	RegisterSyncRoutes(r)
+	RegisterNotificationRoutes(r)
+	RegisterWebhookRoutes(r)
+	RegisterDigestRoutes(r)
+	RegisterInboundRoutes(r)
+}
+
+// Deprecated points clients of the legacy routes at their /api/v1 successor
+func Deprecated() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Deprecation", "true")
+		c.Header("Sunset", legacySunset)
+		c.Header("Link", "<"+APIPrefix+c.Request.URL.Path+`>; rel="successor-version"`)
+		c.Next()
+	}
+}
+
+// NotFound answers unknown paths, in an envelope under /api/v1. It's the
+// engine's NoRoute handler, after the frontend.
+func NotFound(c *gin.Context) {
+	if strings.HasPrefix(c.Request.URL.Path, APIPrefix+"/") {
+		c.JSON(http.StatusNotFound, models.ErrorEnvelope{Error: models.APIError{Code: models.CodeNotFound, Message: "No such endpoint"}})
+		return
+	}
+	c.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+}
+
+// paramID is the :id of the route, or a 400 when it isn't a number. Never
+// hand c.Param("id") to gorm as is, it takes a string there as raw SQL.
+func paramID(c *gin.Context, what string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + what + " ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// db is the handle for a request's queries, carrying its request ID into the
+// query log. They aren't cancelled with the request, a write half done when
+// the client hangs up still finishes.
+func db(c *gin.Context) *gorm.DB {
+	return database.DB.WithContext(context.WithoutCancel(c.Request.Context()))
+}
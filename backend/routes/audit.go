@@ -0,0 +1,173 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rayzox/tickr-backend/models"
+	"gorm.io/gorm"
+)
+
+// Bookkeeping fields that change on every write and would only add noise to the history
+var auditIgnoredFields = map[string]bool{
+	"ID":        true,
+	"CreatedAt": true,
+	"UpdatedAt": true,
+	"DeletedAt": true,
+}
+
+// auditSnapshot flattens a model into its JSON fields so it can be diffed later.
+// Take it before binding a request onto the model, since binding mutates pointer fields in place.
+func auditSnapshot(v interface{}) map[string]interface{} {
+	if v == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+
+	var snapshot map[string]interface{}
+	json.Unmarshal(data, &snapshot)
+
+	for field, value := range snapshot {
+		// Preloaded relationships are audited on their own rows
+		switch value.(type) {
+		case map[string]interface{}, []interface{}:
+			delete(snapshot, field)
+		}
+	}
+
+	return snapshot
+}
+
+func diffSnapshots(before, after map[string]interface{}) map[string]models.FieldChange {
+	changes := map[string]models.FieldChange{}
+
+	for field, newValue := range after {
+		if auditIgnoredFields[field] {
+			continue
+		}
+		oldValue, existed := before[field]
+		if !existed || !reflect.DeepEqual(oldValue, newValue) {
+			changes[field] = models.FieldChange{Old: oldValue, New: newValue}
+		}
+	}
+
+	for field, oldValue := range before {
+		if _, ok := after[field]; !ok && !auditIgnoredFields[field] {
+			changes[field] = models.FieldChange{Old: oldValue, New: nil}
+		}
+	}
+
+	return changes
+}
+
+// auditTrail writes audit entries into the transaction making the change, so
+// a change and its history commit or roll back together. What it recorded is
+// only announced to webhooks and metrics once that transaction has committed.
+type auditTrail struct {
+	c       *gin.Context
+	pending []activity
+}
+
+// activity is one audited change waiting to be announced
+type activity struct {
+	entityType, action string
+	changes            map[string]models.FieldChange
+	after              map[string]interface{}
+}
+
+// audited runs fn in a transaction and publishes what it recorded after the
+// commit, nothing when fn fails
+func audited(c *gin.Context, fn func(tx *gorm.DB, audit *auditTrail) error) error {
+	audit := &auditTrail{c: c}
+	if err := db(c).Transaction(func(tx *gorm.DB) error { return fn(tx, audit) }); err != nil {
+		return err
+	}
+	audit.publish()
+	return nil
+}
+
+// record stores what changed on an entity. before and after are snapshots
+// (or models) of the row; either may be nil for creates and deletes.
+func (a *auditTrail) record(tx *gorm.DB, entityType string, id uint, action string, before, after interface{}) error {
+	beforeSnap, ok := before.(map[string]interface{})
+	if !ok {
+		beforeSnap = auditSnapshot(before)
+	}
+	afterSnap, ok := after.(map[string]interface{})
+	if !ok {
+		afterSnap = auditSnapshot(after)
+	}
+
+	changes := diffSnapshots(beforeSnap, afterSnap)
+	if action == "update" && len(changes) == 0 {
+		return nil
+	}
+
+	entry := models.AuditLog{
+		EntityType: entityType,
+		EntityID:   id,
+		Action:     action,
+		Changes:    changes,
+		Actor:      auditActor(a.c),
+		Client:     auditClient(a.c),
+		IPAddress:  a.c.ClientIP(),
+	}
+	if err := tx.Create(&entry).Error; err != nil {
+		return err
+	}
+
+	a.pending = append(a.pending, activity{entityType: entityType, action: action, changes: changes, after: afterSnap})
+	return nil
+}
+
+// publish emits the webhook events and metrics of the recorded changes, call
+// it once their transaction has committed
+func (a *auditTrail) publish() {
+	for _, act := range a.pending {
+		emitWebhookEvent(act.entityType, act.action, act.changes, act.after)
+		countActivity(act.entityType, act.action, act.after)
+	}
+	a.pending = nil
+}
+
+// auditActor names who is making the request. There are no accounts yet, so
+// clients identify themselves with the X-Tickr-User header.
+func auditActor(c *gin.Context) string {
+	if user := c.GetHeader("X-Tickr-User"); user != "" {
+		return user
+	}
+	return "anonymous"
+}
+
+func auditClient(c *gin.Context) string {
+	if client := c.GetHeader("X-Tickr-Client"); client != "" {
+		return client
+	}
+	return c.Request.UserAgent()
+}
+
+// historyHandler serves the change timeline of one entity type, oldest first
+func historyHandler(entityType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := paramID(c, entityType)
+		if !ok {
+			return
+		}
+
+		var history []models.AuditLog
+		query := db(c).Model(&models.AuditLog{}).Where("entity_type = ? AND entity_id = ?", entityType, id).
+			Order("created_at ASC, id ASC")
+		if err := findPage(c, query, &history); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch history"})
+			return
+		}
+
+		c.JSON(http.StatusOK, history)
+	}
+}
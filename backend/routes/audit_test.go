@@ -0,0 +1,38 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rayzox/tickr-backend/models"
+)
+
+func TestAuditHistory(t *testing.T) {
+	r := testAPI(t)
+
+	var task models.Task
+	mustCall(t, r, http.MethodPost, "/tasks", gin.H{"title": "draft"}, &task)
+	path := fmt.Sprintf("/tasks/%d", task.ID)
+	mustCall(t, r, http.MethodPatch, path, gin.H{"title": "final"}, nil)
+	mustCall(t, r, http.MethodPatch, path, gin.H{"title": "final"}, nil) // no change, no entry
+	// a rolled back bulk leaves no trace either
+	if w := call(t, r, http.MethodPost, "/tasks/bulk", gin.H{"operations": []gin.H{{"action": "complete", "ids": []uint{task.ID, 999}}}}, nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("bulk: status = %d, want 400", w.Code)
+	}
+	mustCall(t, r, http.MethodDelete, path, nil, nil)
+
+	var history []models.AuditLog
+	mustCall(t, r, http.MethodGet, path+"/history", nil, &history)
+	var actions []string
+	for _, entry := range history {
+		actions = append(actions, entry.Action)
+	}
+	if fmt.Sprint(actions) != "[create update delete]" {
+		t.Errorf("actions = %v, want [create update delete]", actions)
+	}
+	if len(history) == 3 && history[1].Changes["title"].New != "final" {
+		t.Errorf("update changes = %v", history[1].Changes)
+	}
+}
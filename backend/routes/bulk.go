@@ -0,0 +1,229 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rayzox/tickr-backend/models"
+	"gorm.io/gorm"
+)
+
+var validPriorities = map[string]bool{"low": true, "medium": true, "high": true}
+
+var errBulkFailed = errors.New("bulk operation failed")
+
+// bulkChange is what one applied item needs for the audit log and undo token
+type bulkChange struct {
+	step    models.UndoStep
+	cascade []models.UndoStep // undo steps for related rows the change touched
+	before  map[string]interface{}
+	after   interface{}
+}
+
+// bulkApplier applies op to the row with the given id inside tx
+type bulkApplier func(tx *gorm.DB, op models.BulkOperation, id uint) (bulkChange, error)
+
+func BulkTasks(c *gin.Context) {
+	runBulk(c, "task", applyTaskOperation)
+}
+
+func BulkCalendarEvents(c *gin.Context) {
+	runBulk(c, "event", applyEventOperation)
+}
+
+// runBulk executes every operation in a single transaction. Either all items
+// succeed or nothing is written, and the response reports each item either way.
+func runBulk(c *gin.Context, entity string, apply bulkApplier) {
+	var request models.BulkRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(request.Operations) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No operations given"})
+		return
+	}
+
+	results := []models.BulkResult{} // operations can still have no ids
+	var changes []bulkChange
+	var response models.BulkResponse
+	failed := false
+
+	err := audited(c, func(tx *gorm.DB, audit *auditTrail) error {
+		for _, op := range request.Operations {
+			for _, id := range op.IDs {
+				change, err := apply(tx, op, id)
+				if err != nil {
+					failed = true
+					results = append(results, models.BulkResult{ID: id, Action: op.Action, Status: "error", Error: err.Error()})
+					continue
+				}
+				changes = append(changes, change)
+				results = append(results, models.BulkResult{ID: id, Action: op.Action, Status: "ok"})
+			}
+		}
+
+		if failed {
+			return errBulkFailed
+		}
+
+		steps := make([]models.UndoStep, 0, len(changes))
+		for _, change := range changes {
+			steps = append(steps, change.step)
+			steps = append(steps, change.cascade...)
+
+			action := "update"
+			if change.step.Restore {
+				action = "delete"
+			}
+			if err := audit.record(tx, entity, change.step.ID, action, change.before, change.after); err != nil {
+				return err
+			}
+		}
+		var err error
+		response.UndoToken, response.UndoExpiresAt, err = issueUndo(tx, entity+".bulk", steps)
+		return err
+	})
+
+	if failed {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No changes were applied, some items failed", "results": results})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to apply bulk operations"})
+		return
+	}
+
+	response.Results, response.Applied = results, len(changes)
+	c.JSON(http.StatusOK, response)
+}
+
+func applyTaskOperation(tx *gorm.DB, op models.BulkOperation, id uint) (bulkChange, error) {
+	var task models.Task
+	if err := tx.First(&task, id).Error; err != nil {
+		return bulkChange{}, errors.New("task not found")
+	}
+
+	change := bulkChange{step: models.UndoStep{Entity: "task", ID: id}, before: auditSnapshot(task)}
+
+	switch op.Action {
+	case "complete":
+		change.step.Previous = map[string]interface{}{"completed": task.Completed}
+		task.Completed = true
+	case "priority":
+		change.step.Previous = map[string]interface{}{"priority": task.Priority}
+		task.Priority = op.Priority
+	case "move":
+		if task.DueDate.IsZero() {
+			return bulkChange{}, errors.New("task has no due date")
+		}
+		change.step.Previous = map[string]interface{}{"due_date": task.DueDate}
+		task.DueDate = task.DueDate.AddDate(0, 0, op.Days)
+	case "add_tag":
+		tags, err := addTag(task.Tags, op.Tag)
+		if err != nil {
+			return bulkChange{}, err
+		}
+		change.step.Previous = map[string]interface{}{"tags": task.Tags}
+		task.Tags = tags
+	case "delete":
+		steps, err := deleteTask(tx, &task, op.WithEvents)
+		if err != nil {
+			return bulkChange{}, err
+		}
+		change.step, change.cascade = steps[0], steps[1:]
+		return change, nil
+	default:
+		return bulkChange{}, fmt.Errorf("unknown action %q", op.Action)
+	}
+
+	if err := validateRow(tx, &task); err != nil {
+		return bulkChange{}, err
+	}
+	if err := tx.Model(&task).Select(previousColumns(change.step)).Updates(&task).Error; err != nil {
+		return bulkChange{}, err
+	}
+	change.after = task
+	return change, nil
+}
+
+func applyEventOperation(tx *gorm.DB, op models.BulkOperation, id uint) (bulkChange, error) {
+	var event models.Event
+	if err := tx.First(&event, id).Error; err != nil {
+		return bulkChange{}, errors.New("event not found")
+	}
+
+	change := bulkChange{step: models.UndoStep{Entity: "event", ID: id}, before: auditSnapshot(event)}
+
+	switch op.Action {
+	case "priority":
+		change.step.Previous = map[string]interface{}{"priority": event.Priority}
+		event.Priority = op.Priority
+	case "move":
+		moved := event.EventDate.AddDate(0, 0, op.Days)
+		date := moved.Format("2006-01-02")
+		if len(event.Date) > len(date) {
+			date = moved.Format("2006-01-02T15:04:05Z07:00")
+		}
+		change.step.Previous = map[string]interface{}{"event_date": event.EventDate, "date": event.Date}
+		event.EventDate, event.Date = moved, date
+	case "add_tag":
+		tags, err := addTag(event.Tags, op.Tag)
+		if err != nil {
+			return bulkChange{}, err
+		}
+		change.step.Previous = map[string]interface{}{"tags": event.Tags}
+		event.Tags = tags
+	case "delete":
+		steps, err := deleteEvent(tx, &event)
+		if err != nil {
+			return bulkChange{}, err
+		}
+		change.step, change.cascade = steps[0], steps[1:]
+		return change, nil
+	default:
+		return bulkChange{}, fmt.Errorf("unknown action %q", op.Action)
+	}
+
+	if err := validateRow(tx, &event); err != nil {
+		return bulkChange{}, err
+	}
+	if err := tx.Model(&event).Select(previousColumns(change.step)).Updates(&event).Error; err != nil {
+		return bulkChange{}, err
+	}
+	change.after = event
+	return change, nil
+}
+
+// previousColumns are the columns an operation changed, the ones its undo step puts back
+func previousColumns(step models.UndoStep) []string {
+	columns := make([]string, 0, len(step.Previous))
+	for column := range step.Previous {
+		columns = append(columns, column)
+	}
+	return columns
+}
+
+// addTag appends tag to a comma-separated list unless it's already there
+func addTag(tags, tag string) (string, error) {
+	tag = strings.TrimSpace(tag)
+	if tag == "" || strings.Contains(tag, ",") {
+		return "", fmt.Errorf("invalid tag %q", tag)
+	}
+
+	var list []string
+	for _, existing := range strings.Split(tags, ",") {
+		existing = strings.TrimSpace(existing)
+		if existing == tag {
+			return tags, nil
+		}
+		if existing != "" {
+			list = append(list, existing)
+		}
+	}
+
+	return strings.Join(append(list, tag), ","), nil
+}
@@ -0,0 +1,79 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rayzox/tickr-backend/models"
+)
+
+func TestBulkTasks(t *testing.T) {
+	r := testAPI(t)
+
+	var a, b models.Task
+	mustCall(t, r, http.MethodPost, "/tasks", gin.H{"title": "a", "priority": "low"}, &a)
+	mustCall(t, r, http.MethodPost, "/tasks", gin.H{"title": "b"}, &b)
+	get := func(id uint) (models.Task, int) {
+		var task models.Task
+		w := call(t, r, http.MethodGet, fmt.Sprintf("/tasks/%d", id), nil, &task)
+		return task, w.Code
+	}
+
+	t.Run("rollback", func(t *testing.T) {
+		var failed struct {
+			Results []models.BulkResult `json:"results"`
+		}
+		w := call(t, r, http.MethodPost, "/tasks/bulk", gin.H{"operations": []gin.H{
+			{"action": "complete", "ids": []uint{a.ID}},
+			{"action": "delete", "ids": []uint{b.ID}},
+			{"action": "priority", "ids": []uint{a.ID}, "priority": "urgent"},
+		}}, nil)
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("status = %d, want 400", w.Code)
+		}
+		decode(t, w, &failed)
+		statuses := []string{}
+		for _, result := range failed.Results {
+			statuses = append(statuses, result.Status)
+		}
+		if fmt.Sprint(statuses) != "[ok ok error]" || failed.Results[2].Error != "Invalid priority" {
+			t.Errorf("results = %+v", failed.Results)
+		}
+
+		if task, _ := get(a.ID); task.Completed {
+			t.Error("a was completed")
+		}
+		if _, code := get(b.ID); code != http.StatusOK {
+			t.Error("b was deleted")
+		}
+	})
+
+	t.Run("apply and undo", func(t *testing.T) {
+		var response models.BulkResponse
+		mustCall(t, r, http.MethodPost, "/tasks/bulk", gin.H{"operations": []gin.H{
+			{"action": "complete", "ids": []uint{a.ID, b.ID}},
+			{"action": "add_tag", "ids": []uint{a.ID}, "tag": "work"},
+		}}, &response)
+		if response.Applied != 3 || response.UndoToken == "" {
+			t.Fatalf("response = %+v", response)
+		}
+		if task, _ := get(a.ID); !task.Completed || task.Tags != "work" {
+			t.Errorf("a = %+v", task)
+		}
+
+		mustCall(t, r, http.MethodPost, "/undo/"+response.UndoToken, nil, nil)
+		for _, id := range []uint{a.ID, b.ID} {
+			if task, _ := get(id); task.Completed || task.Tags != "" {
+				t.Errorf("task %d after undo: completed %v, tags %q", id, task.Completed, task.Tags)
+			}
+		}
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		if code := call(t, r, http.MethodPost, "/tasks/bulk", gin.H{"operations": []gin.H{}}, nil).Code; code != http.StatusBadRequest {
+			t.Errorf("status = %d, want 400", code)
+		}
+	})
+}
@@ -2,34 +2,37 @@ package routes
 
 import (
 	"net/http"
-	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/rayzox/tickr-backend/database"
 	"github.com/rayzox/tickr-backend/models"
+	"gorm.io/gorm"
 )
 
-func RegisterCalendarRoutes(r *gin.Engine) {
+func RegisterCalendarRoutes(r gin.IRouter) {
 	calendar := r.Group("/calendar")
 	{
 		calendar.GET("/events", GetCalendarEvents)
 		calendar.POST("/events", CreateCalendarEvent)
+		calendar.POST("/events/bulk", BulkCalendarEvents)
+		calendar.GET("/events/:id", GetCalendarEvent)
 		calendar.PUT("/events/:id", UpdateCalendarEvent)
+		calendar.PATCH("/events/:id", PatchCalendarEvent)
 		calendar.DELETE("/events/:id", DeleteCalendarEvent)
 		calendar.GET("/events/range", GetEventsInRange)
+		calendar.GET("/events/:id/history", historyHandler("event"))
 	}
 }
 
 func GetCalendarEvents(c *gin.Context) {
 	var events []models.Event
 
-	// Use Find with proper preloading
-	if err := database.DB.
-		Preload("Task").
-		Preload("Habit").
-		Order("event_date ASC").
-		Find(&events).Error; err != nil {
+	// Preloaded after paginate, counting doesn't need them
+	query, err := paginate(c, db(c).Model(&models.Event{}).Order("event_date ASC"))
+	if err == nil {
+		err = query.Preload("Task").Preload("Habit").Find(&events).Error
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch events"})
 		return
 	}
@@ -37,6 +40,21 @@ func GetCalendarEvents(c *gin.Context) {
 	c.JSON(http.StatusOK, events)
 }
 
+func GetCalendarEvent(c *gin.Context) {
+	id, ok := paramID(c, "event")
+	if !ok {
+		return
+	}
+
+	var event models.Event
+	if err := db(c).Preload("Task").Preload("Habit").First(&event, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+
+	respondWithETag(c, &event)
+}
+
 func CreateCalendarEvent(c *gin.Context) {
 	var event models.Event
 	if err := c.ShouldBindJSON(&event); err != nil {
@@ -56,37 +74,77 @@ func CreateCalendarEvent(c *gin.Context) {
 		}
 	}
 
-	if err := database.DB.Create(&event).Error; err != nil {
+	if !checkRow(c, &event) {
+		return
+	}
+
+	err := audited(c, func(tx *gorm.DB, audit *auditTrail) error {
+		if err := tx.Create(&event).Error; err != nil {
+			return err
+		}
+		return audit.record(tx, "event", event.ID, "create", nil, event)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create event"})
 		return
 	}
 
 	// Preload related data before returning
-	database.DB.Preload("Task").Preload("Habit").First(&event, event.ID)
+	db(c).Preload("Task").Preload("Habit").First(&event, event.ID)
 
 	c.JSON(http.StatusOK, event)
 }
 
 func UpdateCalendarEvent(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+	id, ok := paramID(c, "event")
+	if !ok {
 		return
 	}
 
 	var event models.Event
-	if err := database.DB.First(&event, id).Error; err != nil {
+	if err := db(c).First(&event, id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
 		return
 	}
 
+	if !checkIfMatch(c, &event) {
+		return
+	}
+
+	before := auditSnapshot(event)
+	loaded := event.Model
 	if err := c.ShouldBindJSON(&event); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	event.Model = loaded // clients send whole records back, the IDs and timestamps are ours
+
+	if !checkRow(c, &event) {
+		return
+	}
+
+	if !saveIfUnchanged(c, "event", &event, loaded.UpdatedAt, before) {
+		return
+	}
+
+	c.Header("ETag", etagFor(&event))
+	c.JSON(http.StatusOK, event)
+}
+
+// PatchCalendarEvent only changes the fields present in the body (JSON Merge Patch)
+func PatchCalendarEvent(c *gin.Context) {
+	id, ok := paramID(c, "event")
+	if !ok {
+		return
+	}
 
-	if err := database.DB.Save(&event).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update event"})
+	var event models.Event
+	if err := db(c).First(&event, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+
+	if !patchRow(c, "event", &event) {
 		return
 	}
 
@@ -94,24 +152,35 @@ func UpdateCalendarEvent(c *gin.Context) {
 }
 
 func DeleteCalendarEvent(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+	id, ok := paramID(c, "event")
+	if !ok {
 		return
 	}
 
 	var event models.Event
-	if err := database.DB.First(&event, id).Error; err != nil {
+	if err := db(c).First(&event, id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
 		return
 	}
 
-	if err := database.DB.Delete(&event).Error; err != nil {
+	response := models.Message{Message: "Event deleted successfully"}
+	err := audited(c, func(tx *gorm.DB, audit *auditTrail) error {
+		steps, err := deleteEvent(tx, &event)
+		if err != nil {
+			return err
+		}
+		response.UndoToken, response.UndoExpiresAt, err = issueUndo(tx, "event.delete", steps)
+		if err != nil {
+			return err
+		}
+		return audit.record(tx, "event", event.ID, "delete", event, nil)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete event"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Event deleted successfully"})
+	c.JSON(http.StatusOK, response)
 }
 
 func GetEventsInRange(c *gin.Context) {
@@ -136,8 +205,11 @@ func GetEventsInRange(c *gin.Context) {
 	}
 
 	var events []models.Event
-	if err := database.DB.Where("event_date BETWEEN ? AND ?", start, end.AddDate(0, 0, 1)).
-		Preload("Task").Preload("Habit").Order("event_date ASC").Find(&events).Error; err != nil {
+	query, err := paginate(c, db(c).Model(&models.Event{}).Where("event_date BETWEEN ? AND ?", start, end.AddDate(0, 0, 1)).Order("event_date ASC"))
+	if err == nil {
+		err = query.Preload("Task").Preload("Habit").Find(&events).Error
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch events"})
 		return
 	}
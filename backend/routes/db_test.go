@@ -0,0 +1,86 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rayzox/tickr-backend/config"
+	"github.com/rayzox/tickr-backend/database"
+)
+
+// metrics can only be registered once per process, so the tests share one
+// engine and swap the database under it
+var (
+	testEngine     *gin.Engine
+	testEngineOnce sync.Once
+)
+
+// testAPI serves every route against a fresh SQLite database in a temp dir.
+// Rate limiting is off, every test comes from the same client IP.
+func testAPI(t *testing.T) *gin.Engine {
+	t.Helper()
+	withConfig(t, func(cfg *config.Config) { cfg.Security.RateLimit = 0 })
+
+	previous := database.DB
+	database.ConnectDatabase(filepath.Join(t.TempDir(), "tickr.db"))
+	if err := database.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		database.Close()
+		database.DB = previous
+	})
+
+	testEngineOnce.Do(func() {
+		testEngine = gin.New()
+		RegisterAPIRoutes(testEngine)
+	})
+	return testEngine
+}
+
+// call sends body as JSON and decodes the response into out when it's not nil
+func call(t *testing.T, r *gin.Engine, method, path string, body, out interface{}, headers ...string) *httptest.ResponseRecorder {
+	t.Helper()
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	req := httptest.NewRequest(method, path, bytes.NewReader(data))
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	w := serve(r, req)
+	if out != nil && w.Code < 300 {
+		decode(t, w, out)
+	}
+	return w
+}
+
+// decode reads a JSON response body into out
+func decode(t *testing.T, w *httptest.ResponseRecorder, out interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(w.Body.Bytes(), out); err != nil {
+		t.Fatalf("%v: %s", err, w.Body)
+	}
+}
+
+// mustCall is call for requests that have to succeed
+func mustCall(t *testing.T, r *gin.Engine, method, path string, body, out interface{}, headers ...string) {
+	t.Helper()
+	if w := call(t, r, method, path, body, out, headers...); w.Code >= 300 {
+		t.Fatalf("%s %s: status %d: %s", method, path, w.Code, w.Body)
+	}
+}
@@ -0,0 +1,188 @@
+package routes
+
+import (
+	"net/http"
+	"net/mail"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rayzox/tickr-backend/digest"
+	"github.com/rayzox/tickr-backend/models"
+)
+
+func RegisterDigestRoutes(r gin.IRouter) {
+	digests := r.Group("/digests")
+	{
+		digests.GET("/preview", PreviewDigest)
+		digests.GET("/subscriptions", GetDigestSubscriptions)
+		digests.POST("/subscriptions", CreateDigestSubscription)
+		digests.PUT("/subscriptions/:id", UpdateDigestSubscription)
+		digests.DELETE("/subscriptions/:id", DeleteDigestSubscription)
+		digests.POST("/subscriptions/:id/send", SendDigestNow)
+	}
+}
+
+// PreviewDigest renders a digest without sending it.
+// ?kind=daily|weekly, ?format=html|text|json, ?timezone= to render it as someone elsewhere would get it
+func PreviewDigest(c *gin.Context) {
+	timezone := c.Query("timezone")
+	if !validTimezone(timezone) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid timezone"})
+		return
+	}
+
+	d, err := digest.Render(c.DefaultQuery("kind", digest.Daily), time.Now().In(timezoneOrLocal(timezone)))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	switch c.DefaultQuery("format", "html") {
+	case "html":
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(d.HTML))
+	case "text":
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(d.Text))
+	default:
+		c.JSON(http.StatusOK, d)
+	}
+}
+
+// GetDigestSubscriptions lists the subscriptions of the requesting user
+func GetDigestSubscriptions(c *gin.Context) {
+	subscriptions := []models.DigestSubscription{}
+	query := db(c).Model(&models.DigestSubscription{}).Where("user = ?", auditActor(c)).Order("id")
+	if err := findPage(c, query, &subscriptions); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch subscriptions"})
+		return
+	}
+	c.JSON(http.StatusOK, subscriptions)
+}
+
+func CreateDigestSubscription(c *gin.Context) {
+	var request models.DigestSubscriptionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	sub := models.DigestSubscription{User: auditActor(c)}
+	applyDigestSubscription(&sub, request)
+	if !validDigestSubscription(c, &sub) {
+		return
+	}
+
+	if err := db(c).Create(&sub).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create subscription"})
+		return
+	}
+	c.JSON(http.StatusOK, sub)
+}
+
+func UpdateDigestSubscription(c *gin.Context) {
+	sub, ok := findDigestSubscription(c)
+	if !ok {
+		return
+	}
+
+	var request models.DigestSubscriptionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	applyDigestSubscription(&sub, request)
+	if !validDigestSubscription(c, &sub) {
+		return
+	}
+
+	if err := db(c).Save(&sub).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update subscription"})
+		return
+	}
+	c.JSON(http.StatusOK, sub)
+}
+
+func DeleteDigestSubscription(c *gin.Context) {
+	sub, ok := findDigestSubscription(c)
+	if !ok {
+		return
+	}
+	if err := db(c).Delete(&sub).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete subscription"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Unsubscribed"})
+}
+
+// SendDigestNow sends a digest (?kind=daily|weekly) right away, e.g. to check the mail setup
+func SendDigestNow(c *gin.Context) {
+	sub, ok := findDigestSubscription(c)
+	if !ok {
+		return
+	}
+	if !digest.CurrentSMTPConfig().Enabled() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "SMTP is not configured"})
+		return
+	}
+
+	kind := c.DefaultQuery("kind", digest.Daily)
+	if kind != digest.Daily && kind != digest.Weekly {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown digest kind"})
+		return
+	}
+	if err := digest.Deliver(sub, kind, time.Now().In(timezoneOrLocal(sub.Timezone))); err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to send digest: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, models.DigestSent{Message: "Digest sent", Email: sub.Email, Kind: kind})
+}
+
+// findDigestSubscription loads the :id subscription if it belongs to the requesting user
+func findDigestSubscription(c *gin.Context) (models.DigestSubscription, bool) {
+	var sub models.DigestSubscription
+	id, ok := paramID(c, "subscription")
+	if !ok {
+		return sub, false
+	}
+	if err := db(c).Where("user = ?", auditActor(c)).First(&sub, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Subscription not found"})
+		return sub, false
+	}
+	return sub, true
+}
+
+// applyDigestSubscription copies the fields a client may set
+func applyDigestSubscription(sub *models.DigestSubscription, request models.DigestSubscriptionRequest) {
+	sub.Email = request.Email
+	sub.Daily = request.Daily
+	sub.Weekly = request.Weekly
+	sub.SendTime = request.SendTime
+	sub.Timezone = request.Timezone
+}
+
+// validDigestSubscription checks the address, send time and timezone, writing the error response itself
+func validDigestSubscription(c *gin.Context, sub *models.DigestSubscription) bool {
+	if _, err := mail.ParseAddress(sub.Email); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email address"})
+		return false
+	}
+	if sub.SendTime == "" {
+		sub.SendTime = "07:00"
+	}
+	if _, err := time.Parse("15:04", sub.SendTime); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "send_time must be HH:MM"})
+		return false
+	}
+	if !validTimezone(sub.Timezone) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid timezone"})
+		return false
+	}
+	return true
+}
+
+// timezoneOrLocal loads a timezone already checked with validTimezone, empty meaning the server's
+func timezoneOrLocal(name string) *time.Location {
+	if name == "" {
+		return time.Local
+	}
+	loc, _ := time.LoadLocation(name)
+	return loc
+}
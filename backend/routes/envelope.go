@@ -0,0 +1,275 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rayzox/tickr-backend/models"
+	"gorm.io/gorm"
+)
+
+const (
+	apiVersionKey = "tickr:api_version"
+	errorCodeKey  = "tickr:error_code"
+	pageKey       = "tickr:page"
+
+	defaultPerPage = 100
+	maxPerPage     = 500
+)
+
+// Envelope puts the responses of the /api/v1 routes in a models.Envelope, or
+// a models.ErrorEnvelope for errors. Handlers keep writing the bare bodies the
+// legacy routes return, this rewrites them on the way out:
+//
+//   - lists become data with meta, paginated with ?page= and ?per_page=, by
+//     the handler's query when it uses paginate or here otherwise
+//   - a top level "message" moves next to data
+//   - {"error": "..."} gets a code from the status, or from setErrorCode
+//
+// Anything that isn't JSON, like the notification stream, goes out untouched.
+func Envelope() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p, ok := pagination(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorEnvelope{Error: models.APIError{
+				Code: models.CodeBadRequest, Message: "page and per_page must be positive integers",
+			}})
+			return
+		}
+
+		c.Set(apiVersionKey, 1)
+		c.Set(pageKey, p)
+		w := &envelopeWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+
+		if w.buffered {
+			w.flush(c, p)
+		}
+	}
+}
+
+// apiVersion is 1 under /api/v1 and 0 on the legacy routes, for the few
+// handlers whose answer differs beyond the envelope
+func apiVersion(c *gin.Context) int {
+	return c.GetInt(apiVersionKey)
+}
+
+// setErrorCode picks the code of the error response about to be written,
+// when the status alone isn't precise enough
+func setErrorCode(c *gin.Context, code models.ErrorCode) {
+	c.Set(errorCodeKey, code)
+}
+
+// listPage is the page of a list asked for, and its total once paginate
+// counted it
+type listPage struct {
+	page, perPage int
+	total         int64
+	counted       bool
+}
+
+func pagination(c *gin.Context) (*listPage, bool) {
+	p := &listPage{page: 1, perPage: defaultPerPage}
+	if v := c.Query("page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return nil, false
+		}
+		p.page = n
+	}
+	if v := c.Query("per_page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return nil, false
+		}
+		p.perPage = min(n, maxPerPage)
+	}
+	return p, true
+}
+
+// paginate limits a list query to the page asked for under /api/v1, counting
+// all its rows for the meta first. Preloads go on the query it returns. The
+// legacy routes list everything, so their query is left alone.
+func paginate(c *gin.Context, query *gorm.DB) (*gorm.DB, error) {
+	p, ok := c.Value(pageKey).(*listPage)
+	if !ok {
+		return query, nil
+	}
+	query = query.Session(&gorm.Session{})
+	if err := query.Count(&p.total).Error; err != nil {
+		return nil, err
+	}
+	p.counted = true
+	return query.Limit(p.perPage).Offset((p.page - 1) * p.perPage), nil
+}
+
+// findPage loads the rows of paginate's page into dest
+func findPage(c *gin.Context, query *gorm.DB, dest interface{}) error {
+	query, err := paginate(c, query)
+	if err != nil {
+		return err
+	}
+	return query.Find(dest).Error
+}
+
+// envelopeWriter holds back JSON bodies until the handler is done. Whether a
+// response is JSON is decided on its first write, by then the content type is set.
+type envelopeWriter struct {
+	gin.ResponseWriter
+	body     bytes.Buffer
+	decided  bool
+	buffered bool
+}
+
+func (w *envelopeWriter) Write(data []byte) (int, error) {
+	if !w.decide() {
+		return w.ResponseWriter.Write(data)
+	}
+	return w.body.Write(data)
+}
+
+func (w *envelopeWriter) WriteString(s string) (int, error) {
+	if !w.decide() {
+		return w.ResponseWriter.WriteString(s)
+	}
+	return w.body.WriteString(s)
+}
+
+// Unwrap lets http.ResponseController reach the connection, e.g. to lift the
+// write deadline of a stream
+func (w *envelopeWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *envelopeWriter) decide() bool {
+	if !w.decided {
+		w.decided = true
+		w.buffered = strings.HasPrefix(w.Header().Get("Content-Type"), "application/json")
+	}
+	return w.buffered
+}
+
+func (w *envelopeWriter) flush(c *gin.Context, p *listPage) {
+	body := w.body.Bytes()
+
+	var out interface{}
+	if w.Status() >= http.StatusBadRequest {
+		out = errorEnvelope(c, w.Status(), body)
+	} else {
+		out = dataEnvelope(body, p)
+	}
+
+	wrapped, err := json.Marshal(out)
+	if err != nil {
+		wrapped = body // can't happen with bodies that were JSON to begin with
+	}
+	w.ResponseWriter.Write(wrapped)
+}
+
+func dataEnvelope(body []byte, p *listPage) interface{} {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 {
+		return models.Envelope{}
+	}
+
+	switch trimmed[0] {
+	case '[':
+		var items []json.RawMessage
+		if err := json.Unmarshal(trimmed, &items); err != nil {
+			break
+		}
+		if p.counted {
+			// already the page, paginate counted the rest
+			return models.Envelope{Data: items, Meta: p.meta(int(p.total))}
+		}
+		// lists that aren't a single query, like the trash
+		meta := p.meta(len(items))
+		start := min((p.page-1)*p.perPage, len(items))
+		end := min(start+p.perPage, len(items))
+		return models.Envelope{Data: items[start:end], Meta: meta}
+
+	case '{':
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(trimmed, &fields); err != nil {
+			break
+		}
+		var message string
+		if err := json.Unmarshal(fields["message"], &message); err != nil {
+			break // no message, or not a string
+		}
+		delete(fields, "message")
+		if len(fields) == 0 {
+			return models.Envelope{Message: message}
+		}
+		return models.Envelope{Data: fields, Message: message}
+	}
+
+	env := models.Envelope{Data: json.RawMessage(trimmed)}
+	if p.counted {
+		// a page wrapped in an object, like the notification inbox
+		env.Meta = p.meta(int(p.total))
+	}
+	return env
+}
+
+func (p *listPage) meta(total int) *models.Meta {
+	return &models.Meta{Page: p.page, PerPage: p.perPage, Total: total, TotalPages: (total + p.perPage - 1) / p.perPage}
+}
+
+func errorEnvelope(c *gin.Context, status int, body []byte) models.ErrorEnvelope {
+	apiErr := models.APIError{Code: errorCodeFor(status), Message: http.StatusText(status)}
+	if code, ok := c.Get(errorCodeKey); ok {
+		apiErr.Code = code.(models.ErrorCode)
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err == nil {
+		if err := json.Unmarshal(fields["error"], &apiErr.Message); err == nil {
+			delete(fields, "error")
+		}
+		for key, value := range fields {
+			if apiErr.Details == nil {
+				apiErr.Details = map[string]interface{}{}
+			}
+			apiErr.Details[key] = value
+		}
+	}
+	return models.ErrorEnvelope{Error: apiErr}
+}
+
+func errorCodeFor(status int) models.ErrorCode {
+	switch status {
+	case http.StatusBadRequest:
+		return models.CodeBadRequest
+	case http.StatusUnauthorized:
+		return models.CodeUnauthorized
+	case http.StatusForbidden:
+		return models.CodeForbidden
+	case http.StatusNotFound:
+		return models.CodeNotFound
+	case http.StatusConflict:
+		return models.CodeConflict
+	case http.StatusPreconditionFailed:
+		return models.CodePreconditionFailed
+	case http.StatusRequestEntityTooLarge:
+		return models.CodePayloadTooLarge
+	case http.StatusUnprocessableEntity:
+		return models.CodeUnprocessable
+	case http.StatusTooManyRequests:
+		return models.CodeRateLimited
+	case http.StatusBadGateway:
+		return models.CodeUpstreamFailed
+	case http.StatusServiceUnavailable:
+		return models.CodeUnavailable
+	}
+	if status < http.StatusInternalServerError {
+		return models.CodeBadRequest
+	}
+	return models.CodeInternal
+}
@@ -0,0 +1,120 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rayzox/tickr-backend/models"
+)
+
+func TestEnvelopePagination(t *testing.T) {
+	r := testAPI(t)
+
+	var ids []uint
+	for i := 0; i < 5; i++ {
+		var task models.Task
+		mustCall(t, r, http.MethodPost, "/tasks", gin.H{"title": fmt.Sprintf("task %d", i)}, &task)
+		ids = append(ids, task.ID)
+	}
+
+	type page struct {
+		Data []models.Task `json:"data"`
+		Meta models.Meta   `json:"meta"`
+	}
+	for _, tt := range []struct {
+		query string
+		count int
+		meta  models.Meta
+	}{
+		{"", 5, models.Meta{Page: 1, PerPage: defaultPerPage, Total: 5, TotalPages: 1}},
+		{"?per_page=2&page=2", 2, models.Meta{Page: 2, PerPage: 2, Total: 5, TotalPages: 3}},
+		{"?per_page=2&page=3", 1, models.Meta{Page: 3, PerPage: 2, Total: 5, TotalPages: 3}},
+		{"?per_page=2&page=9", 0, models.Meta{Page: 9, PerPage: 2, Total: 5, TotalPages: 3}},
+		{"?per_page=100000", 5, models.Meta{Page: 1, PerPage: maxPerPage, Total: 5, TotalPages: 1}},
+	} {
+		var got page
+		mustCall(t, r, http.MethodGet, "/api/v1/tasks"+tt.query, nil, &got)
+		if len(got.Data) != tt.count || got.Meta != tt.meta {
+			t.Errorf("%q: %d tasks, meta %+v, want %d and %+v", tt.query, len(got.Data), got.Meta, tt.count, tt.meta)
+		}
+	}
+
+	// The trash is merged from several tables and paginated by the envelope
+	for _, id := range ids[:3] {
+		var deleted models.Envelope
+		mustCall(t, r, http.MethodDelete, fmt.Sprintf("/api/v1/tasks/%d", id), nil, &deleted)
+		data, _ := deleted.Data.(map[string]interface{})
+		if deleted.Message == "" || data["message"] != nil || data["undo_token"] == nil {
+			t.Errorf("delete = %+v, want the message moved out of data", deleted)
+		}
+	}
+	var trash struct {
+		Data []models.TrashItem `json:"data"`
+		Meta models.Meta        `json:"meta"`
+	}
+	mustCall(t, r, http.MethodGet, "/api/v1/trash?per_page=2&page=2", nil, &trash)
+	if len(trash.Data) != 1 || trash.Meta.Total != 3 || trash.Meta.TotalPages != 2 {
+		t.Errorf("trash page 2: %d items, meta %+v", len(trash.Data), trash.Meta)
+	}
+
+	for _, query := range []string{"?page=0", "?per_page=-1", "?page=two"} {
+		w := call(t, r, http.MethodGet, "/api/v1/tasks"+query, nil, nil)
+		var body models.ErrorEnvelope
+		decode(t, w, &body)
+		if w.Code != http.StatusBadRequest || body.Error.Code != models.CodeBadRequest {
+			t.Errorf("%q: status %d, error %+v", query, w.Code, body.Error)
+		}
+	}
+}
+
+func TestEnvelopeErrors(t *testing.T) {
+	r := testAPI(t)
+
+	w := call(t, r, http.MethodGet, "/api/v1/tasks/999", nil, nil)
+	var body models.ErrorEnvelope
+	decode(t, w, &body)
+	if w.Code != http.StatusNotFound || body.Error.Code != models.CodeNotFound || body.Error.Message == "" {
+		t.Errorf("missing task: status %d, error %+v", w.Code, body.Error)
+	}
+
+	// Fields next to the error end up in details
+	var task models.Task
+	mustCall(t, r, http.MethodPost, "/tasks", gin.H{"title": "one"}, &task)
+	w = call(t, r, http.MethodPost, "/api/v1/tasks/bulk", gin.H{"operations": []gin.H{
+		{"action": "complete", "ids": []uint{task.ID, 999}},
+	}}, nil)
+	body = models.ErrorEnvelope{}
+	decode(t, w, &body)
+	if w.Code < 400 || body.Error.Message == "" || body.Error.Details == nil {
+		t.Errorf("failed bulk: status %d, error %+v, want details", w.Code, body.Error)
+	}
+}
+
+func TestLegacyRoutes(t *testing.T) {
+	r := testAPI(t)
+
+	var tasks []models.Task
+	w := call(t, r, http.MethodGet, "/tasks?per_page=1", nil, &tasks)
+	if w.Code != http.StatusOK || tasks == nil {
+		t.Fatalf("status %d: %s, want a bare list", w.Code, w.Body)
+	}
+	if w.Header().Get("Deprecation") != "true" || w.Header().Get("Sunset") == "" {
+		t.Errorf("headers = %v, want Deprecation and Sunset", w.Header())
+	}
+	if link := w.Header().Get("Link"); link != `</api/v1/tasks>; rel="successor-version"` {
+		t.Errorf("Link = %q", link)
+	}
+
+	w = call(t, r, http.MethodGet, "/tasks/999", nil, nil)
+	var body map[string]interface{}
+	decode(t, w, &body)
+	if w.Code != http.StatusNotFound || body["error"] == nil {
+		t.Errorf("missing task: status %d, body %v, want a bare error", w.Code, body)
+	}
+
+	if w := call(t, r, http.MethodGet, "/api/v1/tasks", nil, nil); w.Header().Get("Deprecation") != "" {
+		t.Error("/api/v1 route is deprecated")
+	}
+}
@@ -1,97 +1,175 @@
 package routes
 
 import (
-	"log"
 	"net/http"
-	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/rayzox/tickr-backend/database"
 	"github.com/rayzox/tickr-backend/models"
+	"gorm.io/gorm"
 )
 
-func RegisterHabitRoutes(r *gin.Engine) {
+func RegisterHabitRoutes(r gin.IRouter) {
 	r.GET("/habits", GetHabits)
 	r.POST("/habits", CreateHabit)
+	r.GET("/habits/:id", GetHabit)
 	r.PUT("/habits/:id", UpdateHabit)
+	r.PATCH("/habits/:id", PatchHabit)
 	r.DELETE("/habits/:id", DeleteHabit)
+	r.GET("/habits/:id/history", historyHandler("habit"))
 }
 
 func GetHabits(c *gin.Context) {
 	var habits []models.Habit
-	database.DB.Find(&habits)
+	if err := findPage(c, db(c).Model(&models.Habit{}).Order("id"), &habits); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch habits"})
+		return
+	}
 	c.JSON(http.StatusOK, habits)
 }
 
+func GetHabit(c *gin.Context) {
+	id, ok := paramID(c, "habit")
+	if !ok {
+		return
+	}
+
+	var habit models.Habit
+	if err := db(c).First(&habit, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Habit not found"})
+		return
+	}
+	respondWithETag(c, &habit)
+}
+
 func CreateHabit(c *gin.Context) {
 	var habit models.Habit
 	if err := c.ShouldBindJSON(&habit); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	database.DB.Create(&habit)
+	if !checkRow(c, &habit) {
+		return
+	}
+	stampCompletion(nil, &habit)
+	err := audited(c, func(tx *gorm.DB, audit *auditTrail) error {
+		if err := tx.Create(&habit).Error; err != nil {
+			return err
+		}
+		return audit.record(tx, "habit", habit.ID, "create", nil, habit)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create habit"})
+		return
+	}
 	c.JSON(http.StatusOK, habit)
 }
 
 func UpdateHabit(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid habit ID"})
+	id, ok := paramID(c, "habit")
+	if !ok {
 		return
 	}
 
 	var habit models.Habit
-	if err := database.DB.First(&habit, id).Error; err != nil {
+	if err := db(c).First(&habit, id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Habit not found"})
 		return
 	}
 
+	if !checkIfMatch(c, &habit) {
+		return
+	}
+	before := auditSnapshot(habit)
+	loaded := habit.Model
+
 	// Bind JSON and check for errors
 	if err := c.ShouldBindJSON(&habit); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	habit.Model = loaded // clients send whole records back, the IDs and timestamps are ours
+	if !checkRow(c, &habit) {
+		return
+	}
+	stampCompletion(before, &habit)
 
-	// Save the updated habit
-	if err := database.DB.Save(&habit).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update habit"})
+	// Save the updated habit, unless someone else did first
+	if !saveIfUnchanged(c, "habit", &habit, loaded.UpdatedAt, before) {
 		return
 	}
 
+	c.Header("ETag", etagFor(&habit))
 	c.JSON(http.StatusOK, habit)
 }
 
-func DeleteHabit(c *gin.Context) {
-	// Convert id param to uint
-	idParam := c.Param("id")
-	log.Printf("Received ID param: %s", idParam) // Debug log
+// PatchHabit only changes the fields present in the body (JSON Merge Patch)
+func PatchHabit(c *gin.Context) {
+	id, ok := paramID(c, "habit")
+	if !ok {
+		return
+	}
 
-	idUint64, err := strconv.ParseUint(idParam, 10, 32)
-	if err != nil {
-		log.Printf("Error parsing ID: %v", err) // Debug log
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid habit ID"})
+	var habit models.Habit
+	if err := db(c).First(&habit, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Habit not found"})
+		return
+	}
+	if !patchRow(c, "habit", &habit) {
+		return
+	}
+	c.JSON(http.StatusOK, habit)
+}
+
+func DeleteHabit(c *gin.Context) {
+	id, ok := paramID(c, "habit")
+	if !ok {
 		return
 	}
-	id := uint(idUint64)
-	log.Printf("Parsed ID: %d", id) // Debug log
 
 	var habit models.Habit
 	// Use First to check if it exists
-	result := database.DB.First(&habit, id)
+	result := db(c).First(&habit, id)
 	if result.Error != nil {
-		log.Printf("Habit not found with ID %d: %v", id, result.Error) // Debug log
 		c.JSON(http.StatusNotFound, gin.H{"error": "Habit not found"})
 		return
 	}
 
 	// Delete
-	deleteResult := database.DB.Delete(&habit)
-	if deleteResult.Error != nil {
-		log.Printf("Error deleting habit: %v", deleteResult.Error) // Debug log
+	response := models.Message{Message: "Habit deleted"}
+	err := audited(c, func(tx *gorm.DB, audit *auditTrail) error {
+		steps, err := deleteHabit(tx, &habit)
+		if err != nil {
+			return err
+		}
+		response.UndoToken, response.UndoExpiresAt, err = issueUndo(tx, "habit.delete", steps)
+		if err != nil {
+			return err
+		}
+		return audit.record(tx, "habit", habit.ID, "delete", habit, nil)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete habit"})
 		return
 	}
 
-	log.Printf("Successfully deleted habit with ID: %d", id) // Debug log
-	c.JSON(http.StatusOK, gin.H{"message": "Habit deleted"})
+	c.JSON(http.StatusOK, response)
+}
+
+// stampCompletion sets last_completed_at when row is a habit that just got
+// checked, before being its audit snapshot from ahead of the change. The
+// rollover job goes by it, so it can't be left to clients.
+func stampCompletion(before map[string]interface{}, row interface{}) {
+	habit, ok := row.(*models.Habit)
+	if !ok || !habit.CompletedToday || before["completed_today"] == true {
+		return
+	}
+	now := time.Now()
+	habit.LastCompletedAt = &now
+}
+
+// validTimezone accepts IANA names and empty for the server's timezone
+func validTimezone(name string) bool {
+	_, err := time.LoadLocation(name)
+	return err == nil
 }
@@ -0,0 +1,58 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rayzox/tickr-backend/models"
+)
+
+func TestHabitCompletionStamp(t *testing.T) {
+	r := testAPI(t)
+
+	check := func(t *testing.T, check func(habit models.Habit) models.Habit) {
+		t.Helper()
+		var habit models.Habit
+		mustCall(t, r, http.MethodPost, "/habits", gin.H{"name": "read"}, &habit)
+		if habit.LastCompletedAt != nil {
+			t.Fatalf("new habit: last_completed_at = %v, want nil", habit.LastCompletedAt)
+		}
+		if habit = check(habit); habit.LastCompletedAt == nil {
+			t.Errorf("last_completed_at wasn't set")
+		}
+	}
+
+	t.Run("put", func(t *testing.T) {
+		check(t, func(habit models.Habit) models.Habit {
+			habit.CompletedToday = true
+			mustCall(t, r, http.MethodPut, fmt.Sprintf("/habits/%d", habit.ID), habit, &habit)
+			return habit
+		})
+	})
+
+	t.Run("patch", func(t *testing.T) {
+		check(t, func(habit models.Habit) models.Habit {
+			mustCall(t, r, http.MethodPatch, fmt.Sprintf("/habits/%d", habit.ID), gin.H{"completed_today": true}, &habit)
+			return habit
+		})
+	})
+
+	t.Run("sync", func(t *testing.T) {
+		check(t, func(habit models.Habit) models.Habit {
+			var pull models.SyncPullResponse
+			mustCall(t, r, http.MethodGet, "/sync", nil, &pull)
+			var push models.SyncPushResponse
+			mustCall(t, r, http.MethodPost, "/sync", models.SyncPushRequest{Changes: []models.SyncEdit{{
+				Entity: "habit", ID: habit.ID, Operation: "update", BaseCursor: pull.Cursor,
+				Data: map[string]interface{}{"completed_today": true},
+			}}}, &push)
+			if push.Results[0].Status != "applied" {
+				t.Fatalf("push: %s %s", push.Results[0].Status, push.Results[0].Error)
+			}
+			mustCall(t, r, http.MethodGet, fmt.Sprintf("/habits/%d", habit.ID), nil, &habit)
+			return habit
+		})
+	})
+}
@@ -0,0 +1,56 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rayzox/tickr-backend/database"
+	"github.com/rayzox/tickr-backend/models"
+)
+
+var draining atomic.Bool
+
+// RegisterHealthRoutes adds the probes: /healthz says the process is up,
+// /readyz that it can serve requests
+func RegisterHealthRoutes(r *gin.Engine) {
+	r.GET("/healthz", GetHealth)
+	r.GET("/readyz", GetReadiness)
+}
+
+// Drain makes /readyz fail from now on, so load balancers stop sending
+// requests while the ones in flight finish
+func Drain() {
+	draining.Store(true)
+}
+
+func GetHealth(c *gin.Context) {
+	c.JSON(http.StatusOK, models.Health{Status: "ok"})
+}
+
+// GetReadiness checks the database answers and its schema is migrated
+func GetReadiness(c *gin.Context) {
+	health := models.Health{Status: "ok", Checks: map[string]string{"database": "ok", "migrations": "ok"}}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+	if err := database.Ping(ctx); err != nil {
+		health.Checks["database"] = err.Error()
+		health.Status = "unavailable"
+	} else if err := database.CheckSchema(); err != nil {
+		health.Checks["migrations"] = err.Error()
+		health.Status = "unavailable"
+	}
+	if draining.Load() {
+		health.Checks["server"] = "shutting down"
+		health.Status = "unavailable"
+	}
+
+	if health.Status != "ok" {
+		c.JSON(http.StatusServiceUnavailable, health)
+		return
+	}
+	c.JSON(http.StatusOK, health)
+}
@@ -0,0 +1,163 @@
+package routes
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rayzox/tickr-backend/config"
+	"github.com/rayzox/tickr-backend/database"
+	"github.com/rayzox/tickr-backend/models"
+	"gorm.io/gorm/clause"
+)
+
+const idempotencyHeader = "Idempotency-Key"
+
+// IdempotencyRetention is how long a key and its response are kept,
+// idempotency_retention in the config
+func IdempotencyRetention() time.Duration {
+	return config.Get().IdempotencyRetention.Duration
+}
+
+// responseRecorder keeps a copy of everything the handler writes
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *responseRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Idempotency makes POST requests carrying an Idempotency-Key header safe to
+// retry: the first response is stored and replayed for later requests with the
+// same key, method and path instead of running the handler again.
+func Idempotency() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(idempotencyHeader)
+		if c.Request.Method != http.MethodPost || key == "" {
+			c.Next()
+			return
+		}
+
+		if len(key) > 255 {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key is too long"})
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		sum := sha256.Sum256(body)
+
+		record := models.IdempotencyKey{
+			Key:         key,
+			Method:      c.Request.Method,
+			Path:        c.Request.URL.Path,
+			RequestHash: hex.EncodeToString(sum[:]),
+		}
+
+		existing, err := claimIdempotencyKey(&record)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to check idempotency key"})
+			return
+		}
+		if existing != nil {
+			replayIdempotentResponse(c, existing, record.RequestHash)
+			return
+		}
+
+		stored := false
+		defer func() {
+			// Let the client retry for real when the handler failed or panicked
+			if !stored {
+				db(c).Delete(&record)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			return
+		}
+
+		if err := db(c).Model(&record).Updates(models.IdempotencyKey{
+			StatusCode:  status,
+			ContentType: recorder.Header().Get("Content-Type"),
+			Body:        recorder.body.Bytes(),
+		}).Error; err != nil {
+			slog.ErrorContext(c.Request.Context(), "Failed to store idempotent response", "key", key, "error", err)
+			return
+		}
+		stored = true
+	}
+}
+
+// claimIdempotencyKey inserts a placeholder for the key. When the key is
+// already taken it returns the existing record instead.
+func claimIdempotencyKey(record *models.IdempotencyKey) (*models.IdempotencyKey, error) {
+	cutoff := time.Now().Add(-IdempotencyRetention())
+
+	for {
+		result := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 1 {
+			// Expired keys are no use to anyone, clean them up while we're here
+			database.DB.Where("created_at < ?", cutoff).Delete(&models.IdempotencyKey{})
+			return nil, nil
+		}
+
+		var existing models.IdempotencyKey
+		if err := database.DB.Where("key = ? AND method = ? AND path = ?", record.Key, record.Method, record.Path).
+			First(&existing).Error; err != nil {
+			return nil, err
+		}
+
+		// A stale key is treated as if it was never used
+		if existing.CreatedAt.Before(cutoff) {
+			database.DB.Delete(&existing)
+			record.ID = 0
+			continue
+		}
+
+		return &existing, nil
+	}
+}
+
+func replayIdempotentResponse(c *gin.Context, stored *models.IdempotencyKey, requestHash string) {
+	if stored.RequestHash != requestHash {
+		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used for a different request"})
+		return
+	}
+	if stored.StatusCode == 0 {
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is still being processed"})
+		return
+	}
+
+	c.Header("Idempotent-Replayed", "true")
+	c.Data(stored.StatusCode, stored.ContentType, stored.Body)
+	c.Abort()
+}
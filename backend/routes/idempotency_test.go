@@ -0,0 +1,59 @@
+package routes
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rayzox/tickr-backend/database"
+	"github.com/rayzox/tickr-backend/models"
+)
+
+func TestIdempotency(t *testing.T) {
+	r := testAPI(t)
+	countTasks := func() int64 {
+		var n int64
+		database.DB.Model(&models.Task{}).Count(&n)
+		return n
+	}
+
+	var first, replayed models.Task
+	w := call(t, r, http.MethodPost, "/tasks", gin.H{"title": "once"}, &first, "Idempotency-Key", "k1")
+	if w.Code != http.StatusOK || w.Header().Get("Idempotent-Replayed") != "" {
+		t.Fatalf("first: status %d, replayed %q", w.Code, w.Header().Get("Idempotent-Replayed"))
+	}
+	w = call(t, r, http.MethodPost, "/tasks", gin.H{"title": "once"}, &replayed, "Idempotency-Key", "k1")
+	if w.Header().Get("Idempotent-Replayed") != "true" || replayed.ID != first.ID {
+		t.Errorf("retry: replayed %q, id %d, want the first response for %d", w.Header().Get("Idempotent-Replayed"), replayed.ID, first.ID)
+	}
+	if n := countTasks(); n != 1 {
+		t.Errorf("%d tasks, want 1", n)
+	}
+
+	if code := call(t, r, http.MethodPost, "/tasks", gin.H{"title": "other"}, nil, "Idempotency-Key", "k1").Code; code != http.StatusUnprocessableEntity {
+		t.Errorf("same key, other body: status = %d, want 422", code)
+	}
+
+	// Keys are per path, and a failed request can be retried for real
+	var envelope struct {
+		Data models.Task `json:"data"`
+	}
+	mustCall(t, r, http.MethodPost, "/api/v1/tasks", gin.H{"title": "once"}, &envelope, "Idempotency-Key", "k1")
+	if envelope.Data.ID == first.ID {
+		t.Error("/api/v1/tasks replayed the legacy route's response")
+	}
+	w = call(t, r, http.MethodPost, "/api/v1/tasks", gin.H{"title": "once"}, &envelope, "Idempotency-Key", "k1")
+	if w.Header().Get("Idempotent-Replayed") != "true" || envelope.Data.Title != "once" {
+		t.Errorf("v1 replay: %q %s", w.Header().Get("Idempotent-Replayed"), w.Body)
+	}
+	if n := countTasks(); n != 2 {
+		t.Errorf("%d tasks, want 2", n)
+	}
+
+	if code := call(t, r, http.MethodPost, "/tasks", gin.H{"title": "x", "priority": "urgent"}, nil, "Idempotency-Key", "k2").Code; code != http.StatusBadRequest {
+		t.Fatalf("invalid: status = %d, want 400", code)
+	}
+	if code := call(t, r, http.MethodPost, "/tasks", gin.H{"title": "x", "priority": "urgent"}, nil, "Idempotency-Key", "k2").Code; code != http.StatusBadRequest {
+		t.Errorf("invalid again: status = %d, want the stored 400", code)
+	}
+}
@@ -0,0 +1,266 @@
+package routes
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"io"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rayzox/tickr-backend/config"
+	"github.com/rayzox/tickr-backend/dates"
+	"github.com/rayzox/tickr-backend/models"
+	"gorm.io/gorm"
+)
+
+// Forwarded emails can carry attachments, only the text is needed
+const maxInboundEmailSize = 5 << 20
+
+func RegisterInboundRoutes(r gin.IRouter) {
+	inbound := r.Group("/inbound")
+	{
+		inbound.POST("/email", ReceiveEmail)
+		inbound.GET("/senders", GetInboundSenders)
+		inbound.POST("/senders", CreateInboundSender)
+		inbound.DELETE("/senders/:id", DeleteInboundSender)
+	}
+}
+
+// InboundToken is the shared secret mail providers must send along with
+// inbound emails, inbound_token in the config. Inbound email is off while it's
+// empty: the sender allowlist alone only checks a From header anyone can forge.
+func InboundToken() string {
+	return config.Get().InboundToken
+}
+
+// ReceiveEmail creates a task from a raw RFC 822 message, as posted by a mail
+// provider's inbound webhook or piped in by an MTA. The subject becomes the
+// title and can carry tokens: "due:friday" (see dates.ParseDay), "!high" and "#tag".
+// The body becomes the description.
+func ReceiveEmail(c *gin.Context) {
+	token := InboundToken()
+	if token == "" {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Inbound email is disabled, no inbound token is configured"})
+		return
+	}
+	given := c.GetHeader("X-Tickr-Inbound-Token")
+	if given == "" {
+		given = c.Query("token")
+	}
+	if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid inbound token"})
+		return
+	}
+
+	msg, err := mail.ReadMessage(io.LimitReader(c.Request.Body, maxInboundEmailSize))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email: " + err.Error()})
+		return
+	}
+
+	from, err := mail.ParseAddress(msg.Header.Get("From"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid From address"})
+		return
+	}
+
+	var allowed int64
+	if err := db(c).Model(&models.InboundSender{}).Where("LOWER(address) = ?", strings.ToLower(from.Address)).Count(&allowed).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check sender"})
+		return
+	}
+	if allowed == 0 {
+		slog.WarnContext(c.Request.Context(), "Rejected inbound email, sender not allowed", "from", from.Address)
+		c.JSON(http.StatusForbidden, gin.H{"error": "Sender is not allowed to create tasks"})
+		return
+	}
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		subject = msg.Header.Get("Subject")
+	}
+	body, err := plainTextBody(msg.Header.Get("Content-Type"), msg.Header.Get("Content-Transfer-Encoding"), msg.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email body: " + err.Error()})
+		return
+	}
+
+	task, err := taskFromEmail(subject, body, time.Now())
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = audited(c, func(tx *gorm.DB, audit *auditTrail) error {
+		if err := tx.Create(&task).Error; err != nil {
+			return err
+		}
+		return audit.record(tx, "task", task.ID, "create", nil, task)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create task"})
+		return
+	}
+	slog.InfoContext(c.Request.Context(), "Created task from email", "task_id", task.ID, "from", from.Address)
+
+	c.JSON(http.StatusOK, task)
+}
+
+// taskFromEmail turns the subject tokens into task fields and the rest into its title
+func taskFromEmail(subject, body string, now time.Time) (models.Task, error) {
+	task := models.Task{Description: body}
+
+	// Forwarding and replying add these
+	for _, prefix := range []string{"fwd:", "fw:", "re:"} {
+		if strings.HasPrefix(strings.ToLower(subject), prefix) {
+			subject = strings.TrimSpace(subject[len(prefix):])
+		}
+	}
+
+	var title []string
+	for _, word := range strings.Fields(subject) {
+		lower := strings.ToLower(word)
+		switch {
+		case strings.HasPrefix(lower, "due:"):
+			day, err := dates.ParseDay(lower[len("due:"):], now)
+			if err != nil {
+				return task, err
+			}
+			// Same default time as a date-only due_date on POST /tasks
+			task.DueDate = day.Add(9 * time.Hour)
+		case len(word) > 1 && word[0] == '!' && validPriorities[lower[1:]]:
+			task.Priority = lower[1:]
+		case len(word) > 1 && word[0] == '#':
+			tags, err := addTag(task.Tags, word[1:])
+			if err != nil {
+				return task, err
+			}
+			task.Tags = tags
+		default:
+			title = append(title, word)
+		}
+	}
+
+	task.Title = strings.Join(title, " ")
+	if task.Title == "" {
+		return task, errors.New("email subject has no title")
+	}
+	return task, nil
+}
+
+// plainTextBody finds the text/plain part of a message and decodes it. The
+// signature, if marked with the usual "-- " line, is left out.
+func plainTextBody(contentType, encoding string, body io.Reader) (string, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = "text/plain" // no or broken header, RFC 2045 default
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		parts := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := parts.NextPart()
+			if err == io.EOF {
+				return "", nil
+			}
+			if err != nil {
+				return "", err
+			}
+			// NextPart already undoes quoted-printable and drops the header
+			text, err := plainTextBody(part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"), part)
+			if err != nil || text != "" {
+				return text, err
+			}
+		}
+	}
+
+	if mediaType != "text/plain" {
+		return "", nil
+	}
+
+	switch strings.ToLower(encoding) {
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return "", err
+	}
+
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		// Quoted-printable decoding eats the trailing space of "-- "
+		if line == "-- " || line == "--" {
+			lines = lines[:i]
+			break
+		}
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n")), nil
+}
+
+// GetInboundSenders lists the addresses the requesting user allowed to create tasks by email
+func GetInboundSenders(c *gin.Context) {
+	senders := []models.InboundSender{}
+	query := db(c).Model(&models.InboundSender{}).Where("user = ?", auditActor(c)).Order("id")
+	if err := findPage(c, query, &senders); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch senders"})
+		return
+	}
+	c.JSON(http.StatusOK, senders)
+}
+
+func CreateInboundSender(c *gin.Context) {
+	var request models.InboundSenderRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	address, err := mail.ParseAddress(request.Address)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email address"})
+		return
+	}
+	sender := models.InboundSender{User: auditActor(c), Address: strings.ToLower(address.Address)}
+
+	// The unique index catches two requests adding the same address at once too
+	err = db(c).Create(&sender).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Sender is already allowed"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add sender"})
+		return
+	}
+	c.JSON(http.StatusOK, sender)
+}
+
+func DeleteInboundSender(c *gin.Context) {
+	id, ok := paramID(c, "sender")
+	if !ok {
+		return
+	}
+
+	// Hard delete so the address can be added again later
+	result := db(c).Unscoped().Where("user = ?", auditActor(c)).Delete(&models.InboundSender{}, id)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove sender"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Sender not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Sender removed"})
+}
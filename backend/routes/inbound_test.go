@@ -0,0 +1,119 @@
+package routes
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rayzox/tickr-backend/models"
+)
+
+func TestTaskFromEmail(t *testing.T) {
+	monday := time.Date(2026, 10, 19, 8, 30, 0, 0, time.UTC)
+
+	for _, tt := range []struct {
+		subject  string
+		title    string
+		due      time.Time
+		priority string
+		tags     string
+		err      bool
+	}{
+		{subject: "Buy milk", title: "Buy milk"},
+		{subject: "Fwd: Re: Call the bank", title: "Call the bank"},
+		{subject: "Send report due:friday !HIGH #work", title: "Send report",
+			due: time.Date(2026, 10, 23, 9, 0, 0, 0, time.UTC), priority: "high", tags: "work"},
+		{subject: "Renew passport due:2026-11-02 #admin #travel #admin", title: "Renew passport",
+			due: time.Date(2026, 11, 2, 9, 0, 0, 0, time.UTC), tags: "admin,travel"},
+		{subject: "Wow! It works !urgent", title: "Wow! It works !urgent"},
+		{subject: "Party # at home", title: "Party # at home"},
+		{subject: "Dentist due:someday", err: true},
+		{subject: "due:today #solo", err: true},
+	} {
+		task, err := taskFromEmail(tt.subject, "body", monday)
+		if tt.err {
+			if err == nil {
+				t.Errorf("%q: no error", tt.subject)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", tt.subject, err)
+			continue
+		}
+		if task.Title != tt.title || !task.DueDate.Equal(tt.due) || task.Priority != tt.priority || task.Tags != tt.tags {
+			t.Errorf("%q: got %q due %v priority %q tags %q", tt.subject, task.Title, task.DueDate, task.Priority, task.Tags)
+		}
+		if task.Description != "body" {
+			t.Errorf("%q: description = %q", tt.subject, task.Description)
+		}
+	}
+}
+
+func TestPlainTextBody(t *testing.T) {
+	multipartBody := strings.ReplaceAll(`--b1
+Content-Type: multipart/alternative; boundary=b2
+
+--b2
+Content-Type: text/plain; charset=utf-8
+Content-Transfer-Encoding: quoted-printable
+
+Caf=C3=A9 at noon, a very long line that was soft wrapped by the sen=
+der's client.
+--=20
+Sent from my phone
+--b2
+Content-Type: text/html
+
+<p>Caf&eacute; at noon</p>
+--b2--
+--b1
+Content-Type: application/pdf
+Content-Transfer-Encoding: base64
+
+JVBERi0=
+--b1--
+`, "\n", "\r\n")
+
+	for _, tt := range []struct {
+		name, contentType, encoding, body, want string
+	}{
+		{"plain", "text/plain", "", "Pick up\nthe keys\n", "Pick up\nthe keys"},
+		{"no header", "", "", "Just text", "Just text"},
+		{"signature", "text/plain; charset=us-ascii", "7bit", "Notes\n-- \nJane\nACME", "Notes"},
+		{"quoted-printable", "text/plain", "Quoted-Printable", "na=C3=AFve =3D fine", "naïve = fine"},
+		{"base64", "text/plain", "base64", "SGVsbG8gdGhlcmU=", "Hello there"},
+		{"html only", "text/html", "", "<p>Hi</p>", ""},
+		{"multipart", `multipart/mixed; boundary="b1"`, "", multipartBody,
+			"Café at noon, a very long line that was soft wrapped by the sender's client."},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := plainTextBody(tt.contentType, tt.encoding, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInboundSenders(t *testing.T) {
+	r := testAPI(t)
+
+	var sender models.InboundSender
+	mustCall(t, r, http.MethodPost, "/inbound/senders", gin.H{"address": "Jane <Jane@Example.com>"}, &sender)
+	if sender.Address != "jane@example.com" || sender.User != "anonymous" {
+		t.Errorf("sender = %+v", sender)
+	}
+
+	if w := call(t, r, http.MethodPost, "/inbound/senders", gin.H{"address": "jane@example.com"}, nil); w.Code != http.StatusConflict {
+		t.Errorf("same address again: status = %d, want 409", w.Code)
+	}
+	if w := call(t, r, http.MethodPost, "/inbound/senders", gin.H{"address": "not an address"}, nil); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid address: status = %d, want 400", w.Code)
+	}
+}
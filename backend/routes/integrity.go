@@ -0,0 +1,147 @@
+package routes
+
+import (
+	"errors"
+	"time"
+
+	"github.com/rayzox/tickr-backend/models"
+	"gorm.io/gorm"
+)
+
+// Soft deletes never reach the foreign keys, so the rules they enforce on a
+// hard delete are applied here by hand:
+//
+//   task  -> events            detached, or trashed with it on request
+//   task  -> pomodoro sessions detached
+//   task  -> focus sessions    trashed with it
+//   event -> task slot         cleared
+//   habit -> events            trashed with it
+//
+// Rows trashed along with their parent get the parent's exact deleted_at,
+// which is how restoreEntity finds them again. Each function returns the undo
+// steps for the whole operation, the parent's restore step first.
+
+var errReferenceNotFound = errors.New("referenced record not found")
+
+func deleteTask(tx *gorm.DB, task *models.Task, withEvents bool) ([]models.UndoStep, error) {
+	deletedAt, err := softDelete(tx, task, task.ID)
+	if err != nil {
+		return nil, err
+	}
+	steps := []models.UndoStep{{Entity: "task", ID: task.ID, Restore: true, DeletedAt: &deletedAt}}
+
+	if withEvents {
+		if err := tx.Model(&models.Event{}).Where("task_id = ?", task.ID).Update("deleted_at", deletedAt).Error; err != nil {
+			return nil, err
+		}
+	} else {
+		detached, err := detach(tx, &models.Event{}, "event", "task_id", task.ID)
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, detached...)
+	}
+
+	detached, err := detach(tx, &models.PomodoroSession{}, "pomodoro", "task_id", task.ID)
+	if err != nil {
+		return nil, err
+	}
+	steps = append(steps, detached...)
+
+	if err := tx.Model(&models.FocusSession{}).Where("task_id = ?", task.ID).Update("deleted_at", deletedAt).Error; err != nil {
+		return nil, err
+	}
+	return steps, nil
+}
+
+func deleteEvent(tx *gorm.DB, event *models.Event) ([]models.UndoStep, error) {
+	deletedAt, err := softDelete(tx, event, event.ID)
+	if err != nil {
+		return nil, err
+	}
+	steps := []models.UndoStep{{Entity: "event", ID: event.ID, Restore: true, DeletedAt: &deletedAt}}
+
+	detached, err := detach(tx, &models.Task{}, "task", "calendar_event_id", event.ID)
+	if err != nil {
+		return nil, err
+	}
+	return append(steps, detached...), nil
+}
+
+func deleteHabit(tx *gorm.DB, habit *models.Habit) ([]models.UndoStep, error) {
+	deletedAt, err := softDelete(tx, habit, habit.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Model(&models.Event{}).Where("habit_id = ?", habit.ID).Update("deleted_at", deletedAt).Error; err != nil {
+		return nil, err
+	}
+	return []models.UndoStep{{Entity: "habit", ID: habit.ID, Restore: true, DeletedAt: &deletedAt}}, nil
+}
+
+// deleteEntity applies the delete rules for any entity, used where the kind is only known at runtime
+func deleteEntity(tx *gorm.DB, row interface{}) ([]models.UndoStep, error) {
+	switch m := row.(type) {
+	case *models.Task:
+		return deleteTask(tx, m, false)
+	case *models.Event:
+		return deleteEvent(tx, m)
+	case *models.Habit:
+		return deleteHabit(tx, m)
+	}
+
+	if err := tx.Delete(row).Error; err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// softDelete trashes the row and returns the deleted_at that was stored for it
+func softDelete(tx *gorm.DB, row interface{}, id uint) (time.Time, error) {
+	if err := tx.Delete(row).Error; err != nil {
+		return time.Time{}, err
+	}
+
+	var deletedAt time.Time
+	err := tx.Unscoped().Model(row).Select("deleted_at").Where("id = ?", id).Row().Scan(&deletedAt)
+	return deletedAt, err
+}
+
+// detach clears column on the live rows pointing at id, returning steps that set it back
+func detach(tx *gorm.DB, model interface{}, entity, column string, id uint) ([]models.UndoStep, error) {
+	var ids []uint
+	if err := tx.Model(model).Where(column+" = ?", id).Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	if err := tx.Model(model).Where("id IN ?", ids).Update(column, nil).Error; err != nil {
+		return nil, err
+	}
+
+	steps := make([]models.UndoStep, 0, len(ids))
+	for _, rowID := range ids {
+		steps = append(steps, models.UndoStep{Entity: entity, ID: rowID, Previous: map[string]interface{}{column: id}})
+	}
+	return steps, nil
+}
+
+// checkReference makes sure an optional foreign key points at a live row, so a
+// bad ID is reported to the client instead of failing the insert
+func checkReference(tx *gorm.DB, model interface{}, id *uint) error {
+	if id == nil {
+		return nil
+	}
+
+	var count int64
+	if err := tx.Model(model).Where("id = ?", *id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return errReferenceNotFound
+	}
+	return nil
+}
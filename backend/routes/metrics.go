@@ -0,0 +1,59 @@
+package routes
+
+import (
+	"log/slog"
+	"math"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rayzox/tickr-backend/config"
+	"github.com/rayzox/tickr-backend/database"
+	"github.com/rayzox/tickr-backend/metrics"
+	"github.com/rayzox/tickr-backend/models"
+)
+
+// RegisterMetricsRoutes serves GET /metrics for Prometheus, outside /api/v1
+// as scrapers expect it at the root
+func RegisterMetricsRoutes(r *gin.Engine) {
+	metrics.NewGaugeFunc("tickr_focus_sessions_active", "Focus sessions currently running.", func() float64 {
+		var active int64
+		if err := database.DB.Model(&models.FocusSession{}).Where("end_time IS NULL").Count(&active).Error; err != nil {
+			slog.Error("Failed to count active focus sessions", "error", err)
+			return math.NaN() // unknown, a 0 would look like nobody is focusing
+		}
+		return float64(active)
+	})
+
+	r.GET("/metrics", requireBearer(MetricsToken, "Invalid metrics token"), GetMetrics)
+}
+
+// MetricsToken is the bearer token scrapers must send, metrics_token in the
+// config. Empty leaves /metrics open.
+func MetricsToken() string {
+	return config.Get().MetricsToken
+}
+
+func GetMetrics(c *gin.Context) {
+	c.Header("Content-Type", metrics.ContentType)
+	c.Status(http.StatusOK)
+	metrics.WriteTo(c.Writer)
+}
+
+// countActivity updates the metrics that follow what happens to entities,
+// from the same audit entries webhooks are emitted from
+func countActivity(entityType, action string, after map[string]interface{}) {
+	if entityType == "pomodoro" && action == "create" {
+		phase, _ := after["phase"].(string)
+		metrics.PomodorosCompleted.Inc(phaseLabel(phase))
+	}
+}
+
+// phaseLabel keeps the phase label to the ones the frontend uses, the phase
+// is whatever the client sent and each new value would be a new series
+func phaseLabel(phase string) string {
+	switch phase {
+	case "work", "short", "long":
+		return phase
+	}
+	return "other"
+}
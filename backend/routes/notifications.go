@@ -0,0 +1,239 @@
+package routes
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rayzox/tickr-backend/models"
+	"github.com/rayzox/tickr-backend/notify"
+)
+
+// Entities a reminder can be attached to
+var remindableEntities = map[string]bool{"task": true, "event": true, "habit": true}
+
+func RegisterNotificationRoutes(r gin.IRouter) {
+	reminders := r.Group("/reminders")
+	{
+		reminders.GET("", GetReminders)
+		reminders.POST("", CreateReminder)
+		reminders.DELETE("/:id", DeleteReminder)
+	}
+
+	notifications := r.Group("/notifications")
+	{
+		notifications.GET("", GetNotifications)
+		notifications.GET("/stream", StreamNotifications)
+		notifications.POST("/read", MarkAllNotificationsRead)
+		notifications.POST("/:id/read", MarkNotificationRead)
+		notifications.POST("/:id/snooze", SnoozeNotification)
+	}
+}
+
+// GetReminders lists reminders, optionally only the ones for ?entity=&entity_id=
+func GetReminders(c *gin.Context) {
+	query := db(c).Model(&models.Reminder{}).Order("id")
+	if entity := c.Query("entity"); entity != "" {
+		query = query.Where("entity = ?", entity)
+	}
+	if entityID := c.Query("entity_id"); entityID != "" {
+		query = query.Where("entity_id = ?", entityID)
+	}
+
+	reminders := []models.Reminder{}
+	if err := findPage(c, query, &reminders); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reminders"})
+		return
+	}
+	c.JSON(http.StatusOK, reminders)
+}
+
+func CreateReminder(c *gin.Context) {
+	var reminder models.Reminder
+	if err := c.ShouldBindJSON(&reminder); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	reminder.FiredFor = nil
+
+	if reminder.Entity == "" {
+		if reminder.RemindAt == nil || reminder.Title == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "A reminder needs an entity, or a remind_at and a title"})
+			return
+		}
+	} else {
+		if !remindableEntities[reminder.Entity] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown entity"})
+			return
+		}
+		if err := checkReference(db(c), models.Entities[reminder.Entity](), &reminder.EntityID); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Entity not found"})
+			return
+		}
+	}
+	if reminder.OffsetMinutes < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "offset_minutes can't be negative"})
+		return
+	}
+
+	if err := db(c).Create(&reminder).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create reminder"})
+		return
+	}
+	c.JSON(http.StatusOK, reminder)
+}
+
+func DeleteReminder(c *gin.Context) {
+	id, ok := paramID(c, "reminder")
+	if !ok {
+		return
+	}
+
+	result := db(c).Delete(&models.Reminder{}, id)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete reminder"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Reminder not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Reminder deleted"})
+}
+
+// GetNotifications is the inbox, newest first. ?unread=true leaves out the
+// ones already read, snoozed notifications only show up once they're due again.
+// Under /api/v1 it's paged like the lists, the legacy route has the last 100.
+func GetNotifications(c *gin.Context) {
+	now := time.Now()
+	query := db(c).Model(&models.Notification{}).Where("deliver_at <= ?", now).Order("deliver_at DESC")
+	if c.Query("unread") == "true" {
+		query = query.Where("read_at IS NULL")
+	}
+	if apiVersion(c) == 0 {
+		query = query.Limit(100)
+	}
+
+	notifications := []models.Notification{}
+	if err := findPage(c, query, &notifications); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notifications"})
+		return
+	}
+
+	var unread int64
+	if err := db(c).Model(&models.Notification{}).Where("deliver_at <= ? AND read_at IS NULL", now).Count(&unread).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notifications"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.NotificationInbox{Notifications: notifications, Unread: unread})
+}
+
+func MarkNotificationRead(c *gin.Context) {
+	id, ok := paramID(c, "notification")
+	if !ok {
+		return
+	}
+
+	var notification models.Notification
+	if err := db(c).First(&notification, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Notification not found"})
+		return
+	}
+
+	if notification.ReadAt == nil {
+		now := time.Now()
+		notification.ReadAt = &now
+		if err := db(c).Model(&notification).Update("read_at", now).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notification"})
+			return
+		}
+	}
+	c.JSON(http.StatusOK, notification)
+}
+
+func MarkAllNotificationsRead(c *gin.Context) {
+	result := db(c).Model(&models.Notification{}).
+		Where("deliver_at <= ? AND read_at IS NULL", time.Now()).
+		Update("read_at", time.Now())
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notifications"})
+		return
+	}
+	c.JSON(http.StatusOK, models.MarkReadResult{Message: "Notifications marked as read", Updated: result.RowsAffected})
+}
+
+// SnoozeNotification hides a notification for {"minutes": n} (10 by default),
+// after which it comes back unread and is pushed again
+func SnoozeNotification(c *gin.Context) {
+	id, ok := paramID(c, "notification")
+	if !ok {
+		return
+	}
+
+	var request models.SnoozeRequest
+	if err := c.ShouldBindJSON(&request); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if request.Minutes == 0 {
+		request.Minutes = 10
+	}
+	if request.Minutes < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "minutes can't be negative"})
+		return
+	}
+
+	var notification models.Notification
+	if err := db(c).First(&notification, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Notification not found"})
+		return
+	}
+
+	// Set on the row first, the response is the snoozed notification
+	notification.DeliverAt = time.Now().Add(time.Duration(request.Minutes) * time.Minute)
+	notification.ReadAt = nil
+	if err := db(c).Model(&notification).Select("deliver_at", "read_at").Updates(&notification).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to snooze notification"})
+		return
+	}
+	c.JSON(http.StatusOK, notification)
+}
+
+// StreamNotifications pushes notifications as server-sent events the moment
+// they're delivered, as an alternative to polling the inbox
+func StreamNotifications(c *gin.Context) {
+	notifications, stop := notify.Subscribe()
+	defer stop()
+
+	// Keeps proxies from closing an idle connection
+	keepAlive := time.NewTicker(30 * time.Second)
+	defer keepAlive.Stop()
+
+	// The server's write timeout is for ordinary requests, this one stays open
+	http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Writer.WriteHeaderNow()
+	c.Writer.Flush()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case n, ok := <-notifications:
+			if !ok {
+				return false // shutting down
+			}
+			c.SSEvent("notification", n)
+			return true
+		case <-keepAlive.C:
+			c.SSEvent("ping", strconv.FormatInt(time.Now().Unix(), 10))
+			return true
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
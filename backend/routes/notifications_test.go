@@ -0,0 +1,57 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rayzox/tickr-backend/database"
+	"github.com/rayzox/tickr-backend/models"
+)
+
+func TestNotificationInbox(t *testing.T) {
+	r := testAPI(t)
+
+	now := time.Now()
+	for i, title := range []string{"oldest", "older", "newest"} {
+		database.DB.Create(&models.Notification{Title: title, DeliverAt: now.Add(time.Duration(i-3) * time.Minute)})
+	}
+	database.DB.Create(&models.Notification{Title: "later", DeliverAt: now.Add(time.Hour)})
+
+	var page struct {
+		Data models.NotificationInbox `json:"data"`
+		Meta models.Meta              `json:"meta"`
+	}
+	mustCall(t, r, http.MethodGet, "/api/v1/notifications?per_page=2&page=2", nil, &page)
+	if len(page.Data.Notifications) != 1 || page.Data.Notifications[0].Title != "oldest" {
+		t.Errorf("page 2 = %+v, want the oldest one", page.Data.Notifications)
+	}
+	if page.Data.Unread != 3 || page.Meta.Total != 3 || page.Meta.TotalPages != 2 {
+		t.Errorf("unread %d, meta %+v, want 3 unread over 2 pages", page.Data.Unread, page.Meta)
+	}
+
+	var inbox models.NotificationInbox
+	mustCall(t, r, http.MethodGet, "/notifications", nil, &inbox)
+	if len(inbox.Notifications) != 3 || inbox.Notifications[0].Title != "newest" {
+		t.Errorf("legacy inbox = %+v", inbox.Notifications)
+	}
+
+	id := inbox.Notifications[0].ID
+	var read models.Notification
+	mustCall(t, r, http.MethodPost, fmt.Sprintf("/notifications/%d/read", id), nil, &read)
+	if read.ReadAt == nil {
+		t.Error("read: read_at is nil")
+	}
+
+	var snoozed models.Notification
+	mustCall(t, r, http.MethodPost, fmt.Sprintf("/notifications/%d/snooze", id), gin.H{"minutes": 30}, &snoozed)
+	if snoozed.ReadAt != nil || snoozed.DeliverAt.Before(now.Add(29*time.Minute)) {
+		t.Errorf("snoozed: read_at %v, deliver_at %v, want unread in 30 minutes", snoozed.ReadAt, snoozed.DeliverAt)
+	}
+	mustCall(t, r, http.MethodGet, "/notifications", nil, &inbox)
+	if len(inbox.Notifications) != 2 || inbox.Unread != 2 {
+		t.Errorf("after snoozing: %d notifications, %d unread, want 2 and 2", len(inbox.Notifications), inbox.Unread)
+	}
+}
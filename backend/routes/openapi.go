@@ -0,0 +1,199 @@
+package routes
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rayzox/tickr-backend/digest"
+	"github.com/rayzox/tickr-backend/models"
+	"github.com/rayzox/tickr-backend/openapi"
+)
+
+// operations documents every route with its request and response types, in
+// the order of the Register functions. Paths are relative to /api/v1, the
+// document describes the enveloped responses served there. Bodies of routes listed with a Request
+// are validated against it before the handler runs.
+var operations = []openapi.Operation{
+	{Method: "GET", Path: "/tasks", Tag: "tasks", Summary: "List tasks", Response: []models.Task{}},
+	{Method: "POST", Path: "/tasks", Tag: "tasks", Summary: "Create a task", Request: models.Task{}, Response: models.Task{}},
+	{Method: "POST", Path: "/tasks/bulk", Tag: "tasks", Summary: "Apply operations to many tasks at once", Request: models.BulkRequest{}, Response: models.BulkResponse{}},
+	{Method: "GET", Path: "/tasks/:id", Tag: "tasks", Summary: "Get a task, with an ETag", Response: models.Task{}},
+	{Method: "PUT", Path: "/tasks/:id", Tag: "tasks", Summary: "Replace a task", Request: models.Task{}, Response: models.Task{}},
+	{Method: "PATCH", Path: "/tasks/:id", Tag: "tasks", Summary: "Merge patch a task, honours If-Match", Request: models.Task{}, Response: models.Task{}},
+	{Method: "DELETE", Path: "/tasks/:id", Tag: "tasks", Summary: "Move a task to the trash", Response: models.Message{},
+		Query: []openapi.Param{{Name: "with_events", Type: "boolean", Description: "also trash the task's calendar events"}}},
+	{Method: "GET", Path: "/tasks/:id/history", Tag: "tasks", Summary: "Change history of a task", Response: []models.AuditLog{}},
+
+	{Method: "GET", Path: "/habits", Tag: "habits", Summary: "List habits", Response: []models.Habit{}},
+	{Method: "POST", Path: "/habits", Tag: "habits", Summary: "Create a habit", Request: models.Habit{}, Response: models.Habit{}},
+	{Method: "GET", Path: "/habits/:id", Tag: "habits", Summary: "Get a habit, with an ETag", Response: models.Habit{}},
+	{Method: "PUT", Path: "/habits/:id", Tag: "habits", Summary: "Replace a habit", Request: models.Habit{}, Response: models.Habit{}},
+	{Method: "PATCH", Path: "/habits/:id", Tag: "habits", Summary: "Merge patch a habit, honours If-Match", Request: models.Habit{}, Response: models.Habit{}},
+	{Method: "DELETE", Path: "/habits/:id", Tag: "habits", Summary: "Move a habit and its events to the trash", Response: models.Message{}},
+	{Method: "GET", Path: "/habits/:id/history", Tag: "habits", Summary: "Change history of a habit", Response: []models.AuditLog{}},
+
+	{Method: "POST", Path: "/pomodoro/sessions", Tag: "pomodoro", Summary: "Record a pomodoro", Request: models.PomodoroSession{}, Response: models.PomodoroSession{}},
+	{Method: "GET", Path: "/pomodoro/settings", Tag: "pomodoro", Summary: "Default phase lengths", Response: models.PomodoroSettings{}},
+	{Method: "GET", Path: "/pomodoro/stats", Tag: "pomodoro", Summary: "Pomodoro totals", Response: models.PomodoroStats{}},
+	{Method: "GET", Path: "/pomodoro/sessions", Tag: "pomodoro", Summary: "List pomodoros", Response: []models.PomodoroSession{}},
+	{Method: "DELETE", Path: "/pomodoro/sessions", Tag: "pomodoro", Summary: "Move every pomodoro to the trash", Response: models.Message{}},
+	{Method: "GET", Path: "/pomodoro/sessions/:id/history", Tag: "pomodoro", Summary: "Change history of a pomodoro", Response: []models.AuditLog{}},
+
+	{Method: "GET", Path: "/calendar/events", Tag: "calendar", Summary: "List events", Response: []models.Event{}},
+	{Method: "POST", Path: "/calendar/events", Tag: "calendar", Summary: "Create an event", Request: models.Event{}, Response: models.Event{}},
+	{Method: "POST", Path: "/calendar/events/bulk", Tag: "calendar", Summary: "Apply operations to many events at once", Request: models.BulkRequest{}, Response: models.BulkResponse{}},
+	{Method: "GET", Path: "/calendar/events/:id", Tag: "calendar", Summary: "Get an event, with an ETag", Response: models.Event{}},
+	{Method: "PUT", Path: "/calendar/events/:id", Tag: "calendar", Summary: "Replace an event", Request: models.Event{}, Response: models.Event{}},
+	{Method: "PATCH", Path: "/calendar/events/:id", Tag: "calendar", Summary: "Merge patch an event, honours If-Match", Request: models.Event{}, Response: models.Event{}},
+	{Method: "DELETE", Path: "/calendar/events/:id", Tag: "calendar", Summary: "Move an event to the trash", Response: models.Message{}},
+	{Method: "GET", Path: "/calendar/events/range", Tag: "calendar", Summary: "Events between two days, both included", Response: []models.Event{},
+		Query: []openapi.Param{{Name: "start", Type: "string", Description: "2006-01-02", Required: true}, {Name: "end", Type: "string", Description: "2006-01-02", Required: true}}},
+	{Method: "GET", Path: "/calendar/events/:id/history", Tag: "calendar", Summary: "Change history of an event", Response: []models.AuditLog{}},
+
+	{Method: "GET", Path: "/productivity/dashboard", Tag: "productivity", Summary: "Today's tasks, habits, events and focus", Response: models.Dashboard{}},
+	{Method: "POST", Path: "/productivity/focus/start", Tag: "productivity", Summary: "Start a focus session, 409 when one is running", Request: models.FocusStartRequest{}, Response: models.FocusSession{}},
+	{Method: "PUT", Path: "/productivity/focus/:id/complete", Tag: "productivity", Summary: "End a focus session", Request: models.FocusCompleteRequest{}, Response: models.FocusSession{}},
+	{Method: "GET", Path: "/productivity/focus/active", Tag: "productivity", Summary: "The running focus session, null when there's none", Response: (*models.FocusSession)(nil)},
+	{Method: "GET", Path: "/productivity/focus/:id/history", Tag: "productivity", Summary: "Change history of a focus session", Response: []models.AuditLog{}},
+	{Method: "POST", Path: "/productivity/schedule/task", Tag: "productivity", Summary: "Put a task on the calendar", Request: models.ScheduleTaskRequest{}, Response: models.Event{}},
+	{Method: "POST", Path: "/productivity/schedule/habit", Tag: "productivity", Summary: "Put a habit's next occurrences on the calendar", Request: models.ScheduleHabitRequest{}, Response: models.ScheduleHabitResult{}},
+	{Method: "GET", Path: "/productivity/analytics/weekly", Tag: "productivity", Summary: "Day by day stats of the week", Response: models.Weekly{}},
+
+	{Method: "GET", Path: "/trash", Tag: "trash", Summary: "List trashed records", Response: []models.TrashItem{},
+		Query: []openapi.Param{{Name: "type", Type: "string", Description: "task, habit, event, pomodoro or focus"}}},
+	{Method: "DELETE", Path: "/trash", Tag: "trash", Summary: "Purge everything in the trash", Response: models.PurgeResult{}},
+	{Method: "POST", Path: "/trash/:type/:id/restore", Tag: "trash", Summary: "Restore a trashed record", Response: models.RestoreResult{}},
+	{Method: "DELETE", Path: "/trash/:type/:id", Tag: "trash", Summary: "Purge a trashed record", Response: models.Message{}},
+	{Method: "POST", Path: "/undo/:token", Tag: "trash", Summary: "Revert the operation that returned the token", Response: models.UndoResult{}},
+
+	{Method: "GET", Path: "/sync", Tag: "sync", Summary: "Records changed after a cursor", Response: models.SyncPullResponse{},
+		Query: []openapi.Param{{Name: "since", Type: "integer", Description: "cursor of the last pull"}, {Name: "limit", Type: "integer"}}},
+	{Method: "POST", Path: "/sync", Tag: "sync", Summary: "Push offline edits", Request: models.SyncPushRequest{}, Response: models.SyncPushResponse{}},
+
+	{Method: "GET", Path: "/reminders", Tag: "notifications", Summary: "List reminders", Response: []models.Reminder{}},
+	{Method: "POST", Path: "/reminders", Tag: "notifications", Summary: "Create a reminder", Request: models.Reminder{}, Response: models.Reminder{}},
+	{Method: "DELETE", Path: "/reminders/:id", Tag: "notifications", Summary: "Delete a reminder", Response: models.Message{}},
+	{Method: "GET", Path: "/notifications", Tag: "notifications", Summary: "The notification inbox, newest first", Response: models.NotificationInbox{},
+		Query: []openapi.Param{{Name: "unread", Type: "boolean", Description: "leave out read notifications"}}},
+	{Method: "GET", Path: "/notifications/stream", Tag: "notifications", Summary: "Server-sent notification events", ResponseType: "text/event-stream"},
+	{Method: "POST", Path: "/notifications/read", Tag: "notifications", Summary: "Mark every notification as read", Response: models.MarkReadResult{}},
+	{Method: "POST", Path: "/notifications/:id/read", Tag: "notifications", Summary: "Mark a notification as read", Response: models.Notification{}},
+	{Method: "POST", Path: "/notifications/:id/snooze", Tag: "notifications", Summary: "Hide a notification for a few minutes", Request: models.SnoozeRequest{}, Response: models.Notification{}},
+
+	{Method: "GET", Path: "/webhooks", Tag: "webhooks", Summary: "List webhooks and the event types", Response: models.WebhookList{}},
+	{Method: "POST", Path: "/webhooks", Tag: "webhooks", Summary: "Register a webhook, the only response with its secret", Request: models.WebhookRequest{}, Response: models.WebhookCreated{}},
+	{Method: "GET", Path: "/webhooks/:id", Tag: "webhooks", Summary: "Get a webhook", Response: models.Webhook{}},
+	{Method: "PUT", Path: "/webhooks/:id", Tag: "webhooks", Summary: "Replace a webhook", Request: models.WebhookRequest{}, Response: models.Webhook{}},
+	{Method: "DELETE", Path: "/webhooks/:id", Tag: "webhooks", Summary: "Delete a webhook", Response: models.Message{}},
+	{Method: "GET", Path: "/webhooks/:id/deliveries", Tag: "webhooks", Summary: "Delivery log, newest first", Response: []models.WebhookDelivery{}},
+	{Method: "POST", Path: "/webhooks/:id/test", Tag: "webhooks", Summary: "Send a ping event now", Response: models.WebhookDelivery{}},
+
+	{Method: "GET", Path: "/digests/preview", Tag: "digests", Summary: "Render a digest email, as HTML, text or JSON", Response: digest.Digest{},
+		Query: []openapi.Param{{Name: "kind", Type: "string", Description: "daily or weekly"}, {Name: "format", Type: "string", Description: "html, text or json"}, {Name: "timezone", Type: "string"}}},
+	{Method: "GET", Path: "/digests/subscriptions", Tag: "digests", Summary: "List the user's digest subscriptions", Response: []models.DigestSubscription{}},
+	{Method: "POST", Path: "/digests/subscriptions", Tag: "digests", Summary: "Subscribe to digests", Request: models.DigestSubscriptionRequest{}, Response: models.DigestSubscription{}},
+	{Method: "PUT", Path: "/digests/subscriptions/:id", Tag: "digests", Summary: "Replace a subscription", Request: models.DigestSubscriptionRequest{}, Response: models.DigestSubscription{}},
+	{Method: "DELETE", Path: "/digests/subscriptions/:id", Tag: "digests", Summary: "Unsubscribe", Response: models.Message{}},
+	{Method: "POST", Path: "/digests/subscriptions/:id/send", Tag: "digests", Summary: "Send a digest now", Response: models.DigestSent{},
+		Query: []openapi.Param{{Name: "kind", Type: "string", Description: "daily or weekly"}}},
+
+	{Method: "POST", Path: "/inbound/email", Tag: "inbound", Summary: "Create a task from a raw email, 503 while no inbound_token is configured", RequestType: "message/rfc822", Response: models.Task{},
+		Query: []openapi.Param{{Name: "token", Type: "string", Description: "the server's inbound_token, or the X-Tickr-Inbound-Token header"}}},
+	{Method: "GET", Path: "/inbound/senders", Tag: "inbound", Summary: "List the user's allowed senders", Response: []models.InboundSender{}},
+	{Method: "POST", Path: "/inbound/senders", Tag: "inbound", Summary: "Allow a sender", Request: models.InboundSenderRequest{}, Response: models.InboundSender{}},
+	{Method: "DELETE", Path: "/inbound/senders/:id", Tag: "inbound", Summary: "Remove an allowed sender", Response: models.Message{}},
+}
+
+var spec = newSpec()
+
+func newSpec() *openapi.Document {
+	doc := openapi.New("tickr", "1.0.0")
+	doc.Servers = []openapi.Server{{URL: APIPrefix}}
+	doc.Envelope(models.Envelope{}, models.ErrorEnvelope{})
+	for _, op := range operations {
+		doc.Add(op)
+	}
+	return doc
+}
+
+// RegisterOpenAPIRoutes serves the API description. It goes last, /api/v1
+// routes missing from operations are listed as undocumented so the document
+// always covers everything registered.
+func RegisterOpenAPIRoutes(r *gin.Engine) {
+	r.GET("/openapi.json", GetOpenAPISpec)
+	r.GET("/docs", GetAPIDocs)
+
+	for _, route := range r.Routes() {
+		path, ok := strings.CutPrefix(route.Path, APIPrefix)
+		if !ok || spec.Has(route.Method, path) {
+			continue
+		}
+		slog.Warn("Route is missing from the OpenAPI operations", "method", route.Method, "path", route.Path)
+		spec.Add(openapi.Operation{Method: route.Method, Path: path, Summary: "Undocumented"})
+	}
+}
+
+func GetOpenAPISpec(c *gin.Context) {
+	c.JSON(http.StatusOK, spec)
+}
+
+// docsCSP lets the docs page run its own inline script, and no other
+var docsCSP = func() string {
+	page := string(openapi.DocsHTML)
+	start := strings.Index(page, "<script>") + len("<script>")
+	end := strings.Index(page, "</script>")
+	sum := sha256.Sum256([]byte(page[start:end]))
+	return "default-src 'self'; style-src 'unsafe-inline'; script-src 'sha256-" + base64.StdEncoding.EncodeToString(sum[:]) + "'; frame-ancestors 'none'"
+}()
+
+func GetAPIDocs(c *gin.Context) {
+	if c.Writer.Header().Get("Content-Security-Policy") != "" {
+		c.Header("Content-Security-Policy", docsCSP)
+	}
+	c.Data(http.StatusOK, "text/html; charset=utf-8", openapi.DocsHTML)
+}
+
+// ValidateRequests rejects path parameters and JSON bodies that don't match
+// the route's declaration with a 400 before they reach the handler
+func ValidateRequests() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := strings.TrimPrefix(c.FullPath(), APIPrefix)
+		values := make(map[string]string, len(c.Params))
+		for _, p := range c.Params {
+			values[p.Key] = p.Value
+		}
+		if err := spec.ValidatePath(c.Request.Method, route, values); err != nil {
+			setErrorCode(c, models.CodeValidationFailed)
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if c.Request.Body == nil || c.Request.Method == http.MethodGet {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		// Empty bodies are left to the handlers, some treat them as defaults
+		if len(bytes.TrimSpace(body)) > 0 {
+			if err := spec.ValidateRequest(c.Request.Method, route, body); err != nil {
+				setErrorCode(c, models.CodeValidationFailed)
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,87 @@
+package routes
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rayzox/tickr-backend/models"
+)
+
+func TestValidateRequests(t *testing.T) {
+	r := testAPI(t)
+
+	var task models.Task
+	mustCall(t, r, http.MethodPost, "/tasks", gin.H{"title": "existing"}, &task)
+
+	for _, tt := range []struct {
+		name, method, path string
+		body               interface{}
+		error              string // empty when the request gets through
+	}{
+		{"unknown field", "POST", "/tasks", gin.H{"title": "x", "colour": "red"}, "colour: unknown field"},
+		{"wrong type", "POST", "/tasks", gin.H{"title": 5}, "title: expected string"},
+		{"null", "POST", "/tasks", gin.H{"title": nil}, "title: must not be null"},
+		{"not an object", "POST", "/tasks", []string{"x"}, "body: expected object"},
+		{"required", "POST", "/tasks/bulk", gin.H{}, "operations: is required"},
+		{"nested", "POST", "/tasks/bulk", gin.H{"operations": []gin.H{{"action": "complete", "ids": []string{"1"}}}}, "operations[0].ids[0]: expected integer"},
+		{"path id", "GET", "/tasks/abc", nil, "id: expected integer in the path"},
+		{"read-only fields", "PUT", "/tasks/1", gin.H{"ID": 1, "CreatedAt": "2026-10-19T00:00:00Z", "title": "sent back whole"}, ""},
+		{"patch null", "PATCH", "/tasks/1", gin.H{"due_date_parsed": nil, "description": nil}, ""},
+		{"patch unknown", "PATCH", "/tasks/1", gin.H{"colour": "red"}, ""},
+		{"patch wrong type", "PATCH", "/tasks/1", gin.H{"completed": "yes"}, "completed: expected boolean"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var body models.ErrorEnvelope
+			w := call(t, r, tt.method, APIPrefix+tt.path, tt.body, nil)
+			if tt.error == "" {
+				if w.Code == http.StatusBadRequest {
+					t.Errorf("status 400: %s", w.Body)
+				}
+				return
+			}
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want 400: %s", w.Code, w.Body)
+			}
+			decode(t, w, &body)
+			if body.Error.Code != models.CodeValidationFailed || body.Error.Message != tt.error {
+				t.Errorf("error = %+v, want %s %q", body.Error, models.CodeValidationFailed, tt.error)
+			}
+		})
+	}
+
+	// Nothing reached the handlers
+	var tasks []models.Task
+	mustCall(t, r, http.MethodGet, "/tasks", nil, &tasks)
+	if len(tasks) != 1 {
+		t.Errorf("%d tasks, want 1", len(tasks))
+	}
+}
+
+func TestOpenAPISpec(t *testing.T) {
+	r := testAPI(t)
+
+	var doc struct {
+		OpenAPI string `json:"openapi"`
+		Paths   map[string]map[string]struct {
+			Summary string `json:"summary"`
+		} `json:"paths"`
+	}
+	mustCall(t, r, http.MethodGet, "/openapi.json", nil, &doc)
+	if doc.OpenAPI == "" {
+		t.Error("no openapi version")
+	}
+	for _, method := range []string{"get", "put", "patch", "delete"} {
+		if _, ok := doc.Paths["/tasks/{id}"][method]; !ok {
+			t.Errorf("%s /tasks/{id} is missing", method)
+		}
+	}
+	// Every registered /api/v1 route has to be in operations
+	for path, methods := range doc.Paths {
+		for method, op := range methods {
+			if op.Summary == "Undocumented" {
+				t.Errorf("%s %s is undocumented", method, path)
+			}
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var errModified = errors.New("row was modified since it was loaded")
+
+// Fields a merge patch is never allowed to touch, lowercased: JSON field
+// names match case-insensitively, so "id" would set ID just as well
+var immutableFields = map[string]bool{
+	"id":        true,
+	"createdat": true,
+	"updatedat": true,
+	"deletedat": true,
+}
+
+// rowVersion reads the primary key and last update time of any gorm.Model based row
+func rowVersion(row interface{}) (uint, time.Time) {
+	v := reflect.ValueOf(row).Elem()
+	return uint(v.FieldByName("ID").Uint()), v.FieldByName("UpdatedAt").Interface().(time.Time)
+}
+
+// etagFor derives a strong ETag from the row's ID and UpdatedAt, which changes on every save
+func etagFor(row interface{}) string {
+	id, updatedAt := rowVersion(row)
+	return fmt.Sprintf(`"%d-%d"`, id, updatedAt.UnixNano())
+}
+
+// checkIfMatch rejects the request with 412 when the client sent an If-Match
+// header that doesn't match the current version of row
+func checkIfMatch(c *gin.Context, row interface{}) bool {
+	header := c.GetHeader("If-Match")
+	if header == "" || strings.TrimSpace(header) == "*" {
+		return true
+	}
+
+	current := etagFor(row)
+	for _, tag := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(tag), "W/") == current {
+			return true
+		}
+	}
+
+	c.Header("ETag", current)
+	c.JSON(http.StatusPreconditionFailed, gin.H{"error": "Resource has been modified since it was fetched"})
+	return false
+}
+
+// respondWithETag answers a GET for a single row, honouring If-None-Match
+func respondWithETag(c *gin.Context, row interface{}) {
+	etag := etagFor(row)
+	c.Header("ETag", etag)
+	if match := c.GetHeader("If-None-Match"); match != "" && strings.Contains(match, etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.JSON(http.StatusOK, row)
+}
+
+// patchRow applies the request body to an already loaded row as a JSON Merge
+// Patch (RFC 7386) and writes it back only if nobody saved the row in the
+// meantime. It writes the error response itself and returns false on failure.
+func patchRow(c *gin.Context, entity string, row interface{}) bool {
+	if !checkIfMatch(c, row) {
+		return false
+	}
+
+	var patch map[string]interface{}
+	if err := json.NewDecoder(c.Request.Body).Decode(&patch); err != nil || patch == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Body must be a JSON object"})
+		return false
+	}
+
+	before := auditSnapshot(row)
+	_, loadedAt := rowVersion(row)
+
+	if err := mergePatch(row, patch); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	if !checkRow(c, row) {
+		return false
+	}
+	stampCompletion(before, row)
+
+	if !saveIfUnchanged(c, entity, row, loadedAt, before) {
+		return false
+	}
+
+	c.Header("ETag", etagFor(row))
+	return true
+}
+
+// saveIfUnchanged writes every column of row and audits the update from the
+// before snapshot, but only if the row is still the version loaded at
+// loadedAt: two tabs editing the same task must not silently overwrite each
+// other. It answers with the 412 or 500 itself when it doesn't.
+func saveIfUnchanged(c *gin.Context, entity string, row interface{}, loadedAt time.Time, before map[string]interface{}) bool {
+	err := audited(c, func(tx *gorm.DB, audit *auditTrail) error {
+		result := tx.Model(row).Where("updated_at = ?", loadedAt).Select("*").Updates(row)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errModified
+		}
+		id, _ := rowVersion(row)
+		return audit.record(tx, entity, id, "update", before, row)
+	})
+	switch {
+	case errors.Is(err, errModified):
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "Resource has been modified since it was fetched"})
+		return false
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update " + entity})
+		return false
+	}
+	return true
+}
+
+// mergePatch applies patch to row in memory as a JSON Merge Patch: fields in
+// the patch replace the current ones and null resets a field to its zero value
+func mergePatch(row interface{}, patch map[string]interface{}) error {
+	for field, value := range patch {
+		if immutableFields[strings.ToLower(field)] {
+			return fmt.Errorf("Field %s cannot be changed", field)
+		}
+		if _, nested := value.(map[string]interface{}); nested {
+			return fmt.Errorf("Field %s cannot be patched", field)
+		}
+	}
+
+	doc := auditSnapshot(row)
+	for field, value := range patch {
+		if value == nil {
+			delete(doc, field)
+		} else {
+			doc[field] = value
+		}
+	}
+	if err := normalizeDueDate(doc); err != nil {
+		return err
+	}
+
+	merged := reflect.New(reflect.TypeOf(row).Elem())
+	data, _ := json.Marshal(doc)
+	if err := json.Unmarshal(data, merged.Interface()); err != nil {
+		return err
+	}
+	reflect.ValueOf(row).Elem().Set(merged.Elem())
+	return nil
+}
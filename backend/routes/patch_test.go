@@ -0,0 +1,73 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rayzox/tickr-backend/models"
+)
+
+func TestConditionalUpdates(t *testing.T) {
+	r := testAPI(t)
+
+	var task models.Task
+	mustCall(t, r, http.MethodPost, "/tasks", gin.H{"title": "draft", "priority": "low"}, &task)
+	path := fmt.Sprintf("/tasks/%d", task.ID)
+
+	w := call(t, r, http.MethodGet, path, nil, nil)
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("no ETag")
+	}
+	if code := call(t, r, http.MethodGet, path, nil, nil, "If-None-Match", etag).Code; code != http.StatusNotModified {
+		t.Errorf("If-None-Match: status = %d, want 304", code)
+	}
+
+	var patched models.Task
+	w = call(t, r, http.MethodPatch, path, gin.H{"title": "final", "description": nil}, &patched, "If-Match", etag)
+	if w.Code != http.StatusOK || patched.Title != "final" || patched.Priority != "low" {
+		t.Fatalf("PATCH: status %d, %+v", w.Code, patched)
+	}
+	fresh := w.Header().Get("ETag")
+	if fresh == etag {
+		t.Error("ETag didn't change")
+	}
+
+	t.Run("stale If-Match", func(t *testing.T) {
+		w := call(t, r, http.MethodPatch, path, gin.H{"title": "lost"}, nil, "If-Match", etag)
+		if w.Code != http.StatusPreconditionFailed || w.Header().Get("ETag") != fresh {
+			t.Errorf("PATCH: status %d, ETag %s, want 412 with %s", w.Code, w.Header().Get("ETag"), fresh)
+		}
+		task.Title = "lost"
+		if code := call(t, r, http.MethodPut, path, task, nil, "If-Match", etag).Code; code != http.StatusPreconditionFailed {
+			t.Errorf("PUT: status = %d, want 412", code)
+		}
+
+		var current models.Task
+		mustCall(t, r, http.MethodGet, path, nil, &current)
+		if current.Title != "final" {
+			t.Errorf("title = %q, the stale writes went through", current.Title)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, body := range []gin.H{
+			{"priority": "urgent"},
+			{"recurrence": "hourly"},
+			{"id": 99},
+			{"calendar_event": gin.H{"title": "x"}},
+		} {
+			if code := call(t, r, http.MethodPatch, path, body, nil).Code; code != http.StatusBadRequest {
+				t.Errorf("PATCH %v: status = %d, want 400", body, code)
+			}
+		}
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		if code := call(t, r, http.MethodPatch, "/tasks/999", gin.H{"title": "x"}, nil).Code; code != http.StatusNotFound {
+			t.Errorf("status = %d, want 404", code)
+		}
+	})
+}
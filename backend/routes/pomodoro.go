@@ -6,17 +6,20 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/rayzox/tickr-backend/database"
+	"github.com/rayzox/tickr-backend/config"
 	"github.com/rayzox/tickr-backend/models"
+	"gorm.io/gorm"
 )
 
-func RegisterPomodoroRoutes(r *gin.Engine) {
+func RegisterPomodoroRoutes(r gin.IRouter) {
 	pomodoro := r.Group("/pomodoro")
 	{
 		pomodoro.POST("/sessions", CreatePomodoroSession)
 		pomodoro.GET("/stats", GetPomodoroStats)
+		pomodoro.GET("/settings", GetPomodoroSettings)
 		pomodoro.GET("/sessions", GetPomodoroSessions)
 		pomodoro.DELETE("/sessions", ClearPomodoroSessions)
+		pomodoro.GET("/sessions/:id/history", historyHandler("pomodoro"))
 	}
 }
 
@@ -32,7 +35,17 @@ func CreatePomodoroSession(c *gin.Context) {
 		session.CompletedAt = time.Now()
 	}
 
-	if err := database.DB.Create(&session).Error; err != nil {
+	if !checkRow(c, &session) {
+		return
+	}
+
+	err := audited(c, func(tx *gorm.DB, audit *auditTrail) error {
+		if err := tx.Create(&session).Error; err != nil {
+			return err
+		}
+		return audit.record(tx, "pomodoro", session.ID, "create", nil, session)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save session"})
 		return
 	}
@@ -40,22 +53,26 @@ func CreatePomodoroSession(c *gin.Context) {
 	c.JSON(http.StatusOK, session)
 }
 
+func GetPomodoroSettings(c *gin.Context) {
+	c.JSON(http.StatusOK, models.PomodoroSettings(config.Get().Pomodoro))
+}
+
 func GetPomodoroStats(c *gin.Context) {
 	var totalSessions int64
 	var totalMinutes int64
 	var todaySessions int64
 
-	// Get total completed sessions
-	database.DB.Model(&models.PomodoroSession{}).Count(&totalSessions)
-
-	// Get total minutes
-	database.DB.Model(&models.PomodoroSession{}).Select("COALESCE(SUM(duration), 0)").Scan(&totalMinutes)
-
-	// Get today's sessions (from midnight today)
-	today := time.Now().Truncate(24 * time.Hour)
-	database.DB.Model(&models.PomodoroSession{}).
-		Where("completed_at >= ?", today).
-		Count(&todaySessions)
+	today := time.Now().Truncate(24 * time.Hour) // today's sessions count from midnight
+	for _, err := range []error{
+		db(c).Model(&models.PomodoroSession{}).Count(&totalSessions).Error,
+		db(c).Model(&models.PomodoroSession{}).Select("COALESCE(SUM(duration), 0)").Scan(&totalMinutes).Error,
+		db(c).Model(&models.PomodoroSession{}).Where("completed_at >= ?", today).Count(&todaySessions).Error,
+	} {
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch pomodoro stats"})
+			return
+		}
+	}
 
 	stats := models.PomodoroStats{
 		CompletedSessions: int(totalSessions),
@@ -69,17 +86,47 @@ func GetPomodoroStats(c *gin.Context) {
 func GetPomodoroSessions(c *gin.Context) {
 	var sessions []models.PomodoroSession
 
-	// Get recent sessions (last 50)
-	database.DB.Order("completed_at DESC").Limit(50).Find(&sessions)
+	// Recent sessions first, the legacy route only has the last 50
+	query := db(c).Model(&models.PomodoroSession{}).Order("completed_at DESC")
+	if apiVersion(c) == 0 {
+		query = query.Limit(50)
+	}
+	if err := findPage(c, query, &sessions); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sessions"})
+		return
+	}
 
 	c.JSON(http.StatusOK, sessions)
 }
 
 func ClearPomodoroSessions(c *gin.Context) {
-	if err := database.DB.Where("1 = 1").Delete(&models.PomodoroSession{}).Error; err != nil {
+	var sessions []models.PomodoroSession
+	response := models.Message{Message: "All sessions cleared"}
+	err := audited(c, func(tx *gorm.DB, audit *auditTrail) error {
+		if err := tx.Find(&sessions).Error; err != nil {
+			return err
+		}
+
+		steps := make([]models.UndoStep, 0, len(sessions))
+		for i := range sessions {
+			deletedAt, err := softDelete(tx, &sessions[i], sessions[i].ID)
+			if err != nil {
+				return err
+			}
+			steps = append(steps, models.UndoStep{Entity: "pomodoro", ID: sessions[i].ID, Restore: true, DeletedAt: &deletedAt})
+			if err := audit.record(tx, "pomodoro", sessions[i].ID, "delete", sessions[i], nil); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		response.UndoToken, response.UndoExpiresAt, err = issueUndo(tx, "pomodoro.clear", steps)
+		return err
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clear sessions"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "All sessions cleared"})
+	c.JSON(http.StatusOK, response)
 }
@@ -1,22 +1,25 @@
 package routes
 
 import (
+	"errors"
 	"net/http"
-	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/rayzox/tickr-backend/database"
+	"github.com/rayzox/tickr-backend/config"
 	"github.com/rayzox/tickr-backend/models"
+	"github.com/rayzox/tickr-backend/reports"
+	"gorm.io/gorm"
 )
 
-func RegisterProductivityRoutes(r *gin.Engine) {
+func RegisterProductivityRoutes(r gin.IRouter) {
 	productivity := r.Group("/productivity")
 	{
 		productivity.GET("/dashboard", GetDashboardData)
 		productivity.POST("/focus/start", StartFocusSession)
 		productivity.PUT("/focus/:id/complete", CompleteFocusSession)
 		productivity.GET("/focus/active", GetActiveFocusSession)
+		productivity.GET("/focus/:id/history", historyHandler("focus"))
 		productivity.POST("/schedule/task", ScheduleTask)
 		productivity.POST("/schedule/habit", ScheduleHabit)
 		productivity.GET("/analytics/weekly", GetWeeklyAnalytics)
@@ -24,59 +27,25 @@ func RegisterProductivityRoutes(r *gin.Engine) {
 }
 
 func GetDashboardData(c *gin.Context) {
-	today := time.Now().Truncate(24 * time.Hour)
-	tomorrow := today.AddDate(0, 0, 1)
-
-	var todayTasks []models.Task
-	var todayHabits []models.Habit
-	var todayEvents []models.Event
-	var todayPomodoros []models.PomodoroSession
-
-	// Get today's data
-	database.DB.Where("due_date = ?", today.Format("2006-01-02")).Find(&todayTasks)
-	database.DB.Find(&todayHabits)
-	database.DB.Where("event_date >= ? AND event_date < ?", today, tomorrow).Find(&todayEvents)
-	database.DB.Where("completed_at >= ? AND completed_at < ?", today, tomorrow).Find(&todayPomodoros)
-
-	// Get active focus session
-	var activeFocus models.FocusSession
-	database.DB.Where("end_time IS NULL").Preload("Task").First(&activeFocus)
-
-	response := gin.H{
-		"today_tasks":     todayTasks,
-		"today_habits":    todayHabits,
-		"today_events":    todayEvents,
-		"today_pomodoros": todayPomodoros,
-		"active_focus":    activeFocus,
-		"date":            today,
+	dashboard, err := reports.DashboardFor(time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load dashboard"})
+		return
 	}
 
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusOK, dashboard)
 }
 
 func StartFocusSession(c *gin.Context) {
-	var request struct {
-		TaskID          uint `json:"task_id" binding:"required"`
-		PlannedDuration int  `json:"planned_duration"`
-	}
+	var request models.FocusStartRequest
 
 	if err := c.ShouldBindJSON(&request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Check if task exists
-	var task models.Task
-	if err := database.DB.First(&task, request.TaskID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
-		return
-	}
-
-	// Check for active sessions
-	var activeSession models.FocusSession
-	if err := database.DB.Where("end_time IS NULL").First(&activeSession).Error; err == nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "Another focus session is already active"})
-		return
+	if request.PlannedDuration <= 0 {
+		request.PlannedDuration = config.Get().Pomodoro.WorkMinutes
 	}
 
 	session := models.FocusSession{
@@ -85,27 +54,51 @@ func StartFocusSession(c *gin.Context) {
 		PlannedDuration: request.PlannedDuration,
 	}
 
-	if err := database.DB.Create(&session).Error; err != nil {
+	// The check is only a nicer error, idx_focus_sessions_active is what stops
+	// two devices starting a session at the same moment
+	err := audited(c, func(tx *gorm.DB, audit *auditTrail) error {
+		var task models.Task
+		if err := tx.First(&task, request.TaskID).Error; err != nil {
+			return err
+		}
+
+		var active int64
+		if err := tx.Model(&models.FocusSession{}).Where("end_time IS NULL").Count(&active).Error; err != nil {
+			return err
+		}
+		if active > 0 {
+			return gorm.ErrDuplicatedKey
+		}
+
+		if err := tx.Create(&session).Error; err != nil {
+			return err
+		}
+		return audit.record(tx, "focus", session.ID, "create", nil, session)
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		return
+	}
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Another focus session is already active"})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start focus session"})
 		return
 	}
 
-	database.DB.Preload("Task").First(&session, session.ID)
+	db(c).Preload("Task").First(&session, session.ID)
 	c.JSON(http.StatusOK, session)
 }
 
 func CompleteFocusSession(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+	id, ok := paramID(c, "session")
+	if !ok {
 		return
 	}
 
-	var request struct {
-		Notes      string `json:"notes"`
-		Completed  bool   `json:"completed"`
-		PomodoroID *uint  `json:"pomodoro_id"`
-	}
+	var request models.FocusCompleteRequest
 
 	if err := c.ShouldBindJSON(&request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -113,32 +106,49 @@ func CompleteFocusSession(c *gin.Context) {
 	}
 
 	var session models.FocusSession
-	if err := database.DB.First(&session, id).Error; err != nil {
+	if err := db(c).First(&session, id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Focus session not found"})
 		return
 	}
 
+	before := auditSnapshot(session)
 	now := time.Now()
 	session.EndTime = &now
 	session.ActualDuration = int(now.Sub(session.StartTime).Minutes())
 	session.Notes = request.Notes
 	session.Completed = request.Completed
 
-	if err := database.DB.Save(&session).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to complete session"})
-		return
-	}
-
-	// Update task pomodoro count if productive
-	if request.Completed && request.PomodoroID != nil {
-		var task models.Task
-		if err := database.DB.First(&task, session.TaskID).Error; err == nil {
-			task.CompletedPomodoros++
-			database.DB.Save(&task)
+	// Update task pomodoro count if productive, along with the session
+	err := audited(c, func(tx *gorm.DB, audit *auditTrail) error {
+		if err := tx.Save(&session).Error; err != nil {
+			return err
+		}
+		if err := audit.record(tx, "focus", session.ID, "update", before, session); err != nil {
+			return err
+		}
+		if !request.Completed || request.PomodoroID == nil {
+			return nil
+		}
 
-			database.DB.Model(&models.PomodoroSession{}).Where("id = ?", *request.PomodoroID).
-				Update("task_id", task.ID)
+		var t models.Task
+		if err := tx.First(&t, session.TaskID).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		taskBefore := auditSnapshot(t)
+		t.CompletedPomodoros++
+		if err := tx.Save(&t).Error; err != nil {
+			return err
 		}
+		if err := audit.record(tx, "task", t.ID, "update", taskBefore, t); err != nil {
+			return err
+		}
+		return tx.Model(&models.PomodoroSession{}).Where("id = ?", *request.PomodoroID).Update("task_id", t.ID).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to complete session"})
+		return
 	}
 
 	c.JSON(http.StatusOK, session)
@@ -146,19 +156,21 @@ func CompleteFocusSession(c *gin.Context) {
 
 func GetActiveFocusSession(c *gin.Context) {
 	var session models.FocusSession
-	if err := database.DB.Where("end_time IS NULL").Preload("Task").First(&session).Error; err != nil {
+	if err := db(c).Where("end_time IS NULL").Preload("Task").First(&session).Error; err != nil {
+		if apiVersion(c) >= 1 {
+			c.JSON(http.StatusOK, nil) // data: null
+			return
+		}
 		c.JSON(http.StatusOK, gin.H{"active_session": nil})
 		return
 	}
 	c.JSON(http.StatusOK, session)
 }
 
+// ScheduleTask puts a task on the calendar. A task that is already scheduled
+// has its event moved instead of getting a second one.
 func ScheduleTask(c *gin.Context) {
-	var request struct {
-		TaskID    uint      `json:"task_id" binding:"required"`
-		EventDate time.Time `json:"event_date" binding:"required"`
-		Duration  int       `json:"duration"`
-	}
+	var request models.ScheduleTaskRequest
 
 	if err := c.ShouldBindJSON(&request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -166,42 +178,74 @@ func ScheduleTask(c *gin.Context) {
 	}
 
 	var task models.Task
-	if err := database.DB.First(&task, request.TaskID).Error; err != nil {
+	var event models.Event
+	var taskBefore, eventBefore map[string]interface{}
+	moved := false
+
+	err := audited(c, func(tx *gorm.DB, audit *auditTrail) error {
+		if err := tx.First(&task, request.TaskID).Error; err != nil {
+			return err
+		}
+		taskBefore = auditSnapshot(task)
+
+		if task.CalendarEventID != nil {
+			err := tx.First(&event, *task.CalendarEventID).Error
+			if err == nil {
+				moved = true
+			} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+		}
+		if moved {
+			eventBefore = auditSnapshot(event)
+		}
+
+		event.Title = "Work on: " + task.Title
+		event.Description = task.Description
+		event.EventDate = request.EventDate
+		event.Date = request.EventDate.Format("2006-01-02") // Make sure to set the Date field
+		event.Duration = request.Duration
+		event.Priority = task.Priority
+		event.EventType = "task"
+		event.TaskID = &task.ID
+
+		if err := tx.Save(&event).Error; err != nil {
+			return err
+		}
+
+		if moved {
+			return audit.record(tx, "event", event.ID, "update", eventBefore, event)
+		}
+		if err := audit.record(tx, "event", event.ID, "create", nil, event); err != nil {
+			return err
+		}
+		task.CalendarEventID = &event.ID
+		if err := tx.Model(&task).Update("calendar_event_id", event.ID).Error; err != nil {
+			return err
+		}
+		return audit.record(tx, "task", task.ID, "update", taskBefore, task)
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
 		return
 	}
-
-	event := models.Event{
-		Title:       "Work on: " + task.Title,
-		Description: task.Description,
-		EventDate:   request.EventDate,
-		Date:        request.EventDate.Format("2006-01-02"), // Make sure to set the Date field
-		Duration:    request.Duration,
-		Priority:    task.Priority,
-		EventType:   "task",
-		TaskID:      &task.ID,
-	}
-
-	if err := database.DB.Create(&event).Error; err != nil {
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to schedule task"})
 		return
 	}
 
-	task.CalendarEventID = &event.ID
-	database.DB.Save(&task)
-
 	// Preload related data before returning
-	database.DB.Preload("Task").Preload("Habit").First(&event, event.ID)
+	db(c).Preload("Task").Preload("Habit").First(&event, event.ID)
 
 	c.JSON(http.StatusOK, event)
 }
 
+// ScheduleHabit adds the habit's occurrences for the next few days to the
+// calendar. Days that already have one are left alone, or moved to the
+// habit's current target time, so calling it again over an overlapping range
+// doesn't create duplicates.
 func ScheduleHabit(c *gin.Context) {
-	var request struct {
-		HabitID   uint      `json:"habit_id" binding:"required"`
-		StartDate time.Time `json:"start_date" binding:"required"`
-		Days      int       `json:"days"`
-	}
+	var request models.ScheduleHabitRequest
 
 	if err := c.ShouldBindJSON(&request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -209,12 +253,17 @@ func ScheduleHabit(c *gin.Context) {
 	}
 
 	var habit models.Habit
-	if err := database.DB.First(&habit, request.HabitID).Error; err != nil {
+	if err := db(c).First(&habit, request.HabitID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Habit not found"})
 		return
 	}
 
-	var events []models.Event
+	targetTime := "09:00"
+	if habit.TargetTime != "" {
+		targetTime = habit.TargetTime
+	}
+
+	var occurrences []time.Time
 	currentDate := request.StartDate
 
 	for i := 0; i < request.Days; i++ {
@@ -230,79 +279,92 @@ func ScheduleHabit(c *gin.Context) {
 		}
 
 		if shouldSchedule {
-			targetTime := "09:00"
-			if habit.TargetTime != "" {
-				targetTime = habit.TargetTime
-			}
-
 			eventDateTime, _ := time.Parse("2006-01-02 15:04",
 				currentDate.Format("2006-01-02")+" "+targetTime)
-
-			event := models.Event{
-				Title:       "🎯 " + habit.Name,
-				Description: "Habit reminder",
-				EventDate:   eventDateTime,
-				Duration:    30,
-				Priority:    "medium",
-				EventType:   "habit",
-				HabitID:     &habit.ID,
-			}
-			events = append(events, event)
+			occurrences = append(occurrences, eventDateTime)
 		}
 
 		currentDate = currentDate.AddDate(0, 0, 1)
 	}
 
-	if len(events) > 0 {
-		if err := database.DB.Create(&events).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to schedule habits"})
-			return
+	events := []models.Event{}
+	var created, updated int
+
+	err := audited(c, func(tx *gorm.DB, audit *auditTrail) error {
+		if len(occurrences) == 0 {
+			return nil
 		}
+
+		// Occurrences already on the calendar for this habit, by day
+		var existing []models.Event
+		if err := tx.Where("habit_id = ? AND event_date >= ? AND event_date < ?", habit.ID,
+			occurrences[0].AddDate(0, 0, -1), occurrences[len(occurrences)-1].AddDate(0, 0, 1)).
+			Order("event_date").Find(&existing).Error; err != nil {
+			return err
+		}
+		byDay := make(map[string]models.Event, len(existing))
+		for _, event := range existing {
+			day := event.EventDate.Format("2006-01-02")
+			if _, ok := byDay[day]; !ok {
+				byDay[day] = event
+			}
+		}
+
+		for _, occurrence := range occurrences {
+			day := occurrence.Format("2006-01-02")
+			event, ok := byDay[day]
+			if !ok {
+				event = models.Event{
+					Title:       "🎯 " + habit.Name,
+					Description: "Habit reminder",
+					Date:        day,
+					EventDate:   occurrence,
+					Duration:    30,
+					Priority:    "medium",
+					EventType:   "habit",
+					HabitID:     &habit.ID,
+				}
+				if err := tx.Create(&event).Error; err != nil {
+					return err
+				}
+				if err := audit.record(tx, "event", event.ID, "create", nil, event); err != nil {
+					return err
+				}
+				created++
+			} else if !event.EventDate.Equal(occurrence) {
+				before := auditSnapshot(event)
+				if err := tx.Model(&event).Updates(map[string]interface{}{"event_date": occurrence, "date": day}).Error; err != nil {
+					return err
+				}
+				if err := audit.record(tx, "event", event.ID, "update", before, event); err != nil {
+					return err
+				}
+				updated++
+			}
+			events = append(events, event)
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to schedule habits"})
+		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"scheduled_events": len(events), "events": events})
+	c.JSON(http.StatusOK, models.ScheduleHabitResult{
+		ScheduledEvents: len(events),
+		Created:         created,
+		Updated:         updated,
+		Skipped:         len(events) - created - updated,
+		Events:          events,
+	})
 }
 
 func GetWeeklyAnalytics(c *gin.Context) {
-	now := time.Now()
-	startOfWeek := now.AddDate(0, 0, -int(now.Weekday()))
-	startOfWeek = time.Date(startOfWeek.Year(), startOfWeek.Month(), startOfWeek.Day(), 0, 0, 0, 0, startOfWeek.Location())
-
-	var weeklyStats []gin.H
-
-	for i := 0; i < 7; i++ {
-		day := startOfWeek.AddDate(0, 0, i)
-		nextDay := day.AddDate(0, 0, 1)
-
-		var completedTasks, totalTasks int64
-		var completedHabits, totalHabits int64
-		var pomodoroSessions int64
-		var totalPomodoroMinutes int64
-
-		database.DB.Model(&models.Task{}).Where("completed = ? AND updated_at >= ? AND updated_at < ?", true, day, nextDay).Count(&completedTasks)
-		database.DB.Model(&models.Task{}).Where("due_date = ?", day.Format("2006-01-02")).Count(&totalTasks)
-
-		database.DB.Model(&models.Habit{}).Where("completed_today = ? AND updated_at >= ? AND updated_at < ?", true, day, nextDay).Count(&completedHabits)
-		database.DB.Model(&models.Habit{}).Count(&totalHabits)
-
-		database.DB.Model(&models.PomodoroSession{}).Where("completed_at >= ? AND completed_at < ?", day, nextDay).Count(&pomodoroSessions)
-		database.DB.Model(&models.PomodoroSession{}).Where("completed_at >= ? AND completed_at < ? AND phase = ?", day, nextDay, "work").
-			Select("COALESCE(SUM(duration), 0)").Scan(&totalPomodoroMinutes)
-
-		weeklyStats = append(weeklyStats, gin.H{
-			"date":               day.Format("2006-01-02"),
-			"day_name":           day.Format("Monday"),
-			"completed_tasks":    completedTasks,
-			"total_tasks":        totalTasks,
-			"completed_habits":   completedHabits,
-			"total_habits":       totalHabits,
-			"pomodoro_sessions":  pomodoroSessions,
-			"productive_minutes": totalPomodoroMinutes,
-		})
+	weekly, err := reports.WeeklyFor(time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load analytics"})
+		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"week_start":  startOfWeek.Format("2006-01-02"),
-		"daily_stats": weeklyStats,
-	})
+	c.JSON(http.StatusOK, weekly)
 }
@@ -0,0 +1,217 @@
+package routes
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	"github.com/rayzox/tickr-backend/config"
+	"github.com/rayzox/tickr-backend/logging"
+)
+
+// SecurityHeaders go on every response, the frontend's included
+func SecurityHeaders() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cfg := config.Get().Security
+		h := c.Writer.Header()
+		h.Set("X-Content-Type-Options", "nosniff")
+		h.Set("X-Frame-Options", "DENY")
+		h.Set("Referrer-Policy", "strict-origin-when-cross-origin")
+		h.Set("Cross-Origin-Opener-Policy", "same-origin")
+		if cfg.ContentSecurityPolicy != "" {
+			h.Set("Content-Security-Policy", cfg.ContentSecurityPolicy)
+		}
+		if cfg.HSTSMaxAge.Duration > 0 {
+			h.Set("Strict-Transport-Security", fmt.Sprintf("max-age=%d; includeSubDomains", int64(cfg.HSTSMaxAge.Seconds())))
+		}
+		c.Next()
+	}
+}
+
+// CORS lets browsers on the configured origins call the API. With none
+// configured it does nothing and browsers keep to the same origin.
+func CORS() gin.HandlerFunc {
+	origins := config.Get().CORS.AllowedOrigins
+	if len(origins) == 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	cfg := cors.DefaultConfig()
+	cfg.AllowHeaders = append(cfg.AllowHeaders, "Authorization", idempotencyHeader, "If-Match", "If-None-Match",
+		logging.RequestIDHeader, "X-Tickr-User", "X-Tickr-Client")
+	cfg.ExposeHeaders = []string{"ETag", logging.RequestIDHeader, "Retry-After", "Deprecation", "Sunset", "Link"}
+	for _, origin := range origins {
+		if origin == "*" {
+			cfg.AllowAllOrigins = true
+		}
+	}
+	if !cfg.AllowAllOrigins {
+		// cookies only go to origins that were named
+		cfg.AllowOrigins = origins
+		cfg.AllowCredentials = true
+	}
+	return cors.New(cfg)
+}
+
+// bodyLimits are the routes allowed more than security.max_body_bytes
+var bodyLimits = map[string]int64{
+	"/inbound/email": maxInboundEmailSize,
+}
+
+// LimitBody rejects request bodies over the limit with a 413. The body is
+// read up front, the validation and idempotency middleware read it anyway.
+func LimitBody() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Body == nil || c.Request.Body == http.NoBody {
+			c.Next()
+			return
+		}
+
+		limit := config.Get().Security.MaxBodyBytes
+		if routeLimit, ok := bodyLimits[strings.TrimPrefix(c.FullPath(), APIPrefix)]; ok {
+			limit = max(limit, routeLimit)
+		}
+		tooLarge := gin.H{"error": fmt.Sprintf("Request body is larger than %d bytes", limit), "max_bytes": limit}
+
+		if c.Request.ContentLength > limit {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, tooLarge)
+			return
+		}
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, limit))
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, tooLarge)
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		c.Next()
+	}
+}
+
+var limiter = &rateLimiter{buckets: map[string]*bucket{}}
+
+// RateLimit allows each client, by IP, security.rate_limit requests a minute
+// with bursts of security.rate_burst. Over it they get a 429 with Retry-After.
+// Behind a reverse proxy, trusted_proxies has to name it or every request
+// counts against the proxy.
+func RateLimit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cfg := config.Get().Security
+		if cfg.RateLimit == 0 {
+			c.Next()
+			return
+		}
+
+		wait := limiter.take(c.ClientIP(), time.Now(), float64(cfg.RateLimit)/60, float64(cfg.RateBurst))
+		if wait > 0 {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests, slow down"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// rateLimiter is a token bucket per client
+type rateLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	swept   time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// take uses up a token of the client's bucket, or says how long until one is back
+func (l *rateLimiter) take(client string, now time.Time, perSecond, burst float64) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// buckets that have refilled are the same as no bucket
+	if now.Sub(l.swept) > time.Minute {
+		full := time.Duration(burst / perSecond * float64(time.Second))
+		for key, b := range l.buckets {
+			if now.Sub(b.last) >= full {
+				delete(l.buckets, key)
+			}
+		}
+		l.swept = now
+	}
+
+	b, ok := l.buckets[client]
+	if !ok {
+		b = &bucket{tokens: burst, last: now}
+		l.buckets[client] = b
+	}
+	b.tokens = min(burst, b.tokens+now.Sub(b.last).Seconds()*perSecond)
+	b.last = now
+
+	if b.tokens < 1 {
+		return time.Duration((1 - b.tokens) / perSecond * float64(time.Second))
+	}
+	b.tokens--
+	return 0
+}
+
+// CSRF rejects writes made by another site's page in the user's browser,
+// with or without cookies. Browsers say where a request comes from in
+// Sec-Fetch-Site, older ones only in Origin, and pages can't fake either.
+// Requests with neither, like from the CLI or mail providers, don't come
+// from a page. Pages on the origins in cors.allowed_origins are let through.
+func CSRF() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !config.Get().Security.CSRF {
+			c.Next()
+			return
+		}
+
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+		default:
+			if crossSite(c.Request) {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Cross-site requests aren't allowed"})
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+func crossSite(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if trustedOrigin(origin) {
+		return false
+	}
+	switch r.Header.Get("Sec-Fetch-Site") {
+	case "same-origin", "none":
+		return false
+	case "same-site", "cross-site":
+		return true
+	}
+	if origin == "" {
+		return false
+	}
+	u, err := url.Parse(origin) // "null" from sandboxed pages has no host
+	return err != nil || u.Host != r.Host
+}
+
+func trustedOrigin(origin string) bool {
+	return origin != "" && origin != "*" && slices.Contains(config.Get().CORS.AllowedOrigins, origin)
+}
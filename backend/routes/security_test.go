@@ -0,0 +1,279 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rayzox/tickr-backend/config"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// withConfig runs the test with the defaults changed by edit
+func withConfig(t *testing.T, edit func(*config.Config)) {
+	t.Helper()
+	previous := config.Get()
+	cfg := config.Default()
+	edit(cfg)
+	config.Set(cfg)
+	t.Cleanup(func() { config.Set(previous) })
+}
+
+func serve(r *gin.Engine, req *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func ok(c *gin.Context) {
+	c.Status(http.StatusOK)
+}
+
+func TestLimitBody(t *testing.T) {
+	withConfig(t, func(cfg *config.Config) { cfg.Security.MaxBodyBytes = 10 })
+
+	r := gin.New()
+	r.POST("/tasks", LimitBody(), ok)
+	r.POST(APIPrefix+"/inbound/email", LimitBody(), ok)
+
+	tests := []struct {
+		name    string
+		path    string
+		body    string
+		chunked bool
+		want    int
+	}{
+		{"under the limit", "/tasks", "0123456789", false, http.StatusOK},
+		{"over the limit", "/tasks", "0123456789x", false, http.StatusRequestEntityTooLarge},
+		{"over the limit without a length", "/tasks", "0123456789x", true, http.StatusRequestEntityTooLarge},
+		{"inbound email gets more", APIPrefix + "/inbound/email", strings.Repeat("x", 1000), false, http.StatusOK},
+		{"but not unlimited", APIPrefix + "/inbound/email", strings.Repeat("x", maxInboundEmailSize+1), true, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			if tt.chunked {
+				req.ContentLength = -1
+			}
+			if w := serve(r, req); w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimiterTake(t *testing.T) {
+	l := &rateLimiter{buckets: map[string]*bucket{}}
+	now := time.Now()
+
+	// 1 a second, bursts of 2
+	for i := 0; i < 2; i++ {
+		if wait := l.take("a", now, 1, 2); wait != 0 {
+			t.Fatalf("request %d within the burst waited %v", i+1, wait)
+		}
+	}
+	if wait := l.take("a", now, 1, 2); wait != time.Second {
+		t.Errorf("wait after the burst = %v, want 1s", wait)
+	}
+	if wait := l.take("b", now, 1, 2); wait != 0 {
+		t.Errorf("another client waited %v", wait)
+	}
+
+	if wait := l.take("a", now.Add(500*time.Millisecond), 1, 2); wait != 500*time.Millisecond {
+		t.Errorf("wait half way through the refill = %v, want 500ms", wait)
+	}
+	if wait := l.take("a", now.Add(time.Second), 1, 2); wait != 0 {
+		t.Errorf("refilled bucket waited %v", wait)
+	}
+}
+
+func TestRateLimiterSweep(t *testing.T) {
+	l := &rateLimiter{buckets: map[string]*bucket{}}
+	now := time.Now()
+	l.take("idle", now, 1, 2)
+	l.take("busy", now, 1, 2)
+
+	// by then idle has refilled and is dropped, busy just came back
+	later := now.Add(2 * time.Minute)
+	l.buckets["busy"].last = later
+	l.take("other", later, 1, 2)
+
+	if _, ok := l.buckets["idle"]; ok {
+		t.Error("refilled bucket wasn't swept")
+	}
+	if _, ok := l.buckets["busy"]; !ok {
+		t.Error("bucket in use was swept")
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	withConfig(t, func(cfg *config.Config) {
+		cfg.Security.RateLimit = 60
+		cfg.Security.RateBurst = 1
+	})
+	previous := limiter
+	limiter = &rateLimiter{buckets: map[string]*bucket{}}
+	t.Cleanup(func() { limiter = previous })
+
+	r := gin.New()
+	r.GET("/tasks", RateLimit(), ok)
+	request := func(addr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+		req.RemoteAddr = addr
+		return serve(r, req)
+	}
+
+	if w := request("192.0.2.1:1234"); w.Code != http.StatusOK {
+		t.Fatalf("first request: status = %d", w.Code)
+	}
+	w := request("192.0.2.1:1235")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request: status = %d, want 429", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("Retry-After = %q, want 1", got)
+	}
+	if w := request("192.0.2.2:1234"); w.Code != http.StatusOK {
+		t.Errorf("other client: status = %d", w.Code)
+	}
+}
+
+func TestCSRF(t *testing.T) {
+	withConfig(t, func(cfg *config.Config) { cfg.CORS.AllowedOrigins = []string{"https://app.example.com"} })
+
+	r := gin.New()
+	r.Use(CSRF())
+	r.GET("/tasks", ok)
+	r.POST("/tasks", ok)
+
+	tests := []struct {
+		name    string
+		method  string
+		headers map[string]string
+		want    int
+	}{
+		{"no browser", http.MethodPost, nil, http.StatusOK},
+		{"same origin", http.MethodPost, map[string]string{"Sec-Fetch-Site": "same-origin", "Origin": "http://tickr.test"}, http.StatusOK},
+		{"cross site", http.MethodPost, map[string]string{"Sec-Fetch-Site": "cross-site", "Origin": "https://evil.example"}, http.StatusForbidden},
+		{"cross site without cookies", http.MethodPost, map[string]string{"Sec-Fetch-Site": "cross-site"}, http.StatusForbidden},
+		{"same site", http.MethodPost, map[string]string{"Sec-Fetch-Site": "same-site", "Origin": "https://other.tickr.test"}, http.StatusForbidden},
+		{"allowed origin", http.MethodPost, map[string]string{"Sec-Fetch-Site": "cross-site", "Origin": "https://app.example.com"}, http.StatusOK},
+		{"old browser, same origin", http.MethodPost, map[string]string{"Origin": "http://tickr.test"}, http.StatusOK},
+		{"old browser, other origin", http.MethodPost, map[string]string{"Origin": "https://evil.example"}, http.StatusForbidden},
+		{"sandboxed page", http.MethodPost, map[string]string{"Origin": "null"}, http.StatusForbidden},
+		{"cross site read", http.MethodGet, map[string]string{"Sec-Fetch-Site": "cross-site", "Origin": "https://evil.example"}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "http://tickr.test/tasks", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			if w := serve(r, req); w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		config.Get().Security.CSRF = false
+		req := httptest.NewRequest(http.MethodPost, "http://tickr.test/tasks", nil)
+		req.Header.Set("Sec-Fetch-Site", "cross-site")
+		if w := serve(r, req); w.Code != http.StatusOK {
+			t.Errorf("status = %d, want 200", w.Code)
+		}
+	})
+}
+
+func TestCORS(t *testing.T) {
+	preflight := func(r *gin.Engine, origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodOptions, "/tasks", nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		req.Header.Set("Access-Control-Request-Headers", "idempotency-key")
+		return serve(r, req)
+	}
+	engine := func() *gin.Engine {
+		r := gin.New()
+		r.Use(CORS())
+		r.POST("/tasks", ok)
+		return r
+	}
+
+	t.Run("allowlist", func(t *testing.T) {
+		withConfig(t, func(cfg *config.Config) { cfg.CORS.AllowedOrigins = []string{"https://app.example.com"} })
+		r := engine()
+
+		w := preflight(r, "https://app.example.com")
+		if w.Code != http.StatusNoContent {
+			t.Fatalf("allowed origin: status = %d", w.Code)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+			t.Errorf("Access-Control-Allow-Origin = %q", got)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
+			t.Errorf("Access-Control-Allow-Credentials = %q", got)
+		}
+
+		if w := preflight(r, "https://evil.example"); w.Code != http.StatusForbidden {
+			t.Errorf("other origin: status = %d, want 403", w.Code)
+		}
+	})
+
+	t.Run("any", func(t *testing.T) {
+		withConfig(t, func(cfg *config.Config) { cfg.CORS.AllowedOrigins = []string{"*"} })
+		w := preflight(engine(), "https://evil.example")
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+			t.Errorf("Access-Control-Allow-Origin = %q, want *", got)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+			t.Errorf("credentials allowed for any origin")
+		}
+	})
+
+	t.Run("none", func(t *testing.T) {
+		withConfig(t, func(cfg *config.Config) {})
+		w := preflight(engine(), "https://app.example.com")
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+			t.Errorf("Access-Control-Allow-Origin = %q without allowed origins", got)
+		}
+	})
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	withConfig(t, func(cfg *config.Config) {})
+	r := gin.New()
+	r.Use(SecurityHeaders())
+	r.GET("/", ok)
+
+	w := serve(r, httptest.NewRequest(http.MethodGet, "/", nil))
+	for header, want := range map[string]string{
+		"X-Content-Type-Options":  "nosniff",
+		"X-Frame-Options":         "DENY",
+		"Referrer-Policy":         "strict-origin-when-cross-origin",
+		"Content-Security-Policy": config.Default().Security.ContentSecurityPolicy,
+	} {
+		if got := w.Header().Get(header); got != want {
+			t.Errorf("%s = %q, want %q", header, got, want)
+		}
+	}
+	if got := w.Header().Get("Strict-Transport-Security"); got != "" {
+		t.Errorf("HSTS sent by default: %q", got)
+	}
+
+	config.Get().Security.HSTSMaxAge = config.Duration{Duration: 24 * time.Hour}
+	config.Get().Security.ContentSecurityPolicy = ""
+	w = serve(r, httptest.NewRequest(http.MethodGet, "/", nil))
+	if got := w.Header().Get("Strict-Transport-Security"); got != "max-age=86400; includeSubDomains" {
+		t.Errorf("Strict-Transport-Security = %q", got)
+	}
+	if got := w.Header().Get("Content-Security-Policy"); got != "" {
+		t.Errorf("Content-Security-Policy = %q when turned off", got)
+	}
+}
@@ -0,0 +1,245 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rayzox/tickr-backend/database"
+	"github.com/rayzox/tickr-backend/models"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultSyncLimit = 500
+	maxSyncLimit     = 5000
+)
+
+var errSyncConflict = errors.New("record was changed on the server since base_cursor")
+
+func RegisterSyncRoutes(r gin.IRouter) {
+	r.GET("/sync", PullChanges)
+	r.POST("/sync", PushChanges)
+}
+
+// PullChanges returns every record created, updated or deleted after the
+// ?since cursor, oldest change first. Clients store the returned cursor and
+// keep pulling while has_more is true.
+func PullChanges(c *gin.Context) {
+	since, err := strconv.ParseUint(c.DefaultQuery("since", "0"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid since cursor"})
+		return
+	}
+
+	limit := defaultSyncLimit
+	if v := c.Query("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit < 1 || limit > maxSyncLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+	}
+
+	// Only the latest change per record matters, a client that missed ten
+	// updates to a task just needs its current state
+	var latest []struct {
+		Entity   string
+		EntityID uint
+		Cursor   uint
+	}
+	if err := db(c).Model(&models.Change{}).
+		Select("entity, entity_id, MAX(id) AS cursor").
+		Where("id > ?", since).
+		Group("entity, entity_id").
+		Order("cursor ASC").
+		Limit(limit + 1).
+		Scan(&latest).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read changes"})
+		return
+	}
+
+	hasMore := len(latest) > limit
+	if hasMore {
+		latest = latest[:limit]
+	}
+
+	records := make([]models.SyncRecord, 0, len(latest))
+	for _, change := range latest {
+		newModel, ok := models.Entities[change.Entity]
+		if !ok {
+			continue
+		}
+
+		record := models.SyncRecord{Entity: change.Entity, ID: change.EntityID, Cursor: change.Cursor}
+		row := newModel()
+		err := db(c).Unscoped().First(row, change.EntityID).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			record.Deleted = true // purged for good
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read changes"})
+			return
+		case isDeleted(row):
+			record.Deleted = true
+		default:
+			record.Data = row
+		}
+		records = append(records, record)
+	}
+
+	cursor := uint(since)
+	if len(records) > 0 {
+		cursor = records[len(records)-1].Cursor
+	} else {
+		cursor = latestCursor(cursor)
+	}
+
+	c.JSON(http.StatusOK, models.SyncPullResponse{Cursor: cursor, HasMore: hasMore, Changes: records})
+}
+
+// PushChanges applies a batch of offline edits. An edit whose record changed
+// on the server after its base_cursor is not applied and is reported as a
+// conflict with the server's version, the other edits still go through.
+// Changes written by earlier edits of the same push don't count as conflicts,
+// so a client can push several offline edits to one record at once.
+func PushChanges(c *gin.Context) {
+	var request models.SyncPushRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	results := make([]models.SyncPushResult, 0, len(request.Changes))
+	var written []uint // change feed rows this push wrote
+	for _, edit := range request.Changes {
+		result := models.SyncPushResult{ClientID: edit.ClientID, Entity: edit.Entity, ID: edit.ID, Status: "applied"}
+
+		var ids []uint
+		err := audited(c, func(tx *gorm.DB, audit *auditTrail) error {
+			var start uint
+			if err := tx.Model(&models.Change{}).Select("COALESCE(MAX(id), 0)").Scan(&start).Error; err != nil {
+				return err
+			}
+
+			before, after, err := applySyncEdit(tx, &edit, written)
+			if err != nil {
+				return err
+			}
+			if err := audit.record(tx, edit.Entity, edit.ID, edit.Operation, before, after); err != nil {
+				return err
+			}
+
+			return tx.Model(&models.Change{}).Where("id > ?", start).Pluck("id", &ids).Error
+		})
+
+		switch {
+		case errors.Is(err, errSyncConflict):
+			result.Status = "conflict"
+			result.Error = err.Error()
+			result.Server = currentRecord(edit.Entity, edit.ID)
+		case err != nil:
+			result.Status = "error"
+			result.Error = err.Error()
+		default:
+			result.ID = edit.ID
+			result.Cursors = ids
+			written = append(written, ids...)
+		}
+
+		results = append(results, result)
+	}
+
+	c.JSON(http.StatusOK, models.SyncPushResponse{Results: results})
+}
+
+// applySyncEdit writes one pushed edit, filling in edit.ID for creates. Changes
+// listed in own were written earlier in the same push and are not conflicts.
+func applySyncEdit(tx *gorm.DB, edit *models.SyncEdit, own []uint) (map[string]interface{}, interface{}, error) {
+	newModel, ok := models.Entities[edit.Entity]
+	if !ok {
+		return nil, nil, errors.New("unknown entity " + edit.Entity)
+	}
+	row := newModel()
+
+	if edit.Operation == "create" {
+		if err := mergePatch(row, edit.Data); err != nil {
+			return nil, nil, err
+		}
+		if err := validateRow(tx, row); err != nil {
+			return nil, nil, err
+		}
+		stampCompletion(nil, row)
+		if err := tx.Create(row).Error; err != nil {
+			return nil, nil, err
+		}
+		edit.ID, _ = rowVersion(row)
+		return nil, row, nil
+	}
+
+	if edit.Operation != "update" && edit.Operation != "delete" {
+		return nil, nil, errors.New("unknown op " + edit.Operation)
+	}
+
+	var newer int64
+	query := tx.Model(&models.Change{}).
+		Where("entity = ? AND entity_id = ? AND id > ?", edit.Entity, edit.ID, edit.BaseCursor)
+	if len(own) > 0 {
+		query = query.Where("id NOT IN ?", own)
+	}
+	if err := query.Count(&newer).Error; err != nil {
+		return nil, nil, err
+	}
+	if newer > 0 {
+		return nil, nil, errSyncConflict
+	}
+
+	if err := tx.First(row, edit.ID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, errSyncConflict // deleted on the server without a newer change, e.g. before the feed existed
+		}
+		return nil, nil, err
+	}
+	before := auditSnapshot(row)
+
+	if edit.Operation == "delete" {
+		_, err := deleteEntity(tx, row)
+		return before, nil, err
+	}
+
+	if err := mergePatch(row, edit.Data); err != nil {
+		return nil, nil, err
+	}
+	if err := validateRow(tx, row); err != nil {
+		return nil, nil, err
+	}
+	stampCompletion(before, row)
+	return before, row, tx.Select("*").Updates(row).Error
+}
+
+// currentRecord loads the live version of a record, nil when it's deleted
+func currentRecord(entity string, id uint) interface{} {
+	newModel, ok := models.Entities[entity]
+	if !ok {
+		return nil
+	}
+	row := newModel()
+	if err := database.DB.First(row, id).Error; err != nil {
+		return nil
+	}
+	return row
+}
+
+// latestCursor is the newest position in the change feed, or fallback when the feed is empty
+func latestCursor(fallback uint) uint {
+	var change models.Change
+	if err := database.DB.Order("id DESC").First(&change).Error; err != nil {
+		return fallback
+	}
+	return change.ID
+}
+
+func isDeleted(row interface{}) bool {
+	return reflect.ValueOf(row).Elem().FieldByName("DeletedAt").Interface().(gorm.DeletedAt).Valid
+}
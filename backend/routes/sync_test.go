@@ -0,0 +1,171 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rayzox/tickr-backend/models"
+)
+
+func TestPushChangesConflicts(t *testing.T) {
+	r := testAPI(t)
+
+	var task models.Task
+	mustCall(t, r, http.MethodPost, "/tasks", gin.H{"title": "draft"}, &task)
+	var pull models.SyncPullResponse
+	mustCall(t, r, http.MethodGet, "/sync", nil, &pull)
+	base := pull.Cursor
+
+	push := func(edits ...models.SyncEdit) []models.SyncPushResult {
+		t.Helper()
+		var response models.SyncPushResponse
+		mustCall(t, r, http.MethodPost, "/sync", models.SyncPushRequest{Changes: edits}, &response)
+		return response.Results
+	}
+	edit := func(title string) models.SyncEdit {
+		return models.SyncEdit{Entity: "task", ID: task.ID, Operation: "update", BaseCursor: base, Data: map[string]interface{}{"title": title}}
+	}
+
+	t.Run("own edits", func(t *testing.T) {
+		results := push(edit("first"), edit("second"))
+		for i, result := range results {
+			if result.Status != "applied" {
+				t.Errorf("edit %d: status = %q (%s), want applied", i, result.Status, result.Error)
+			}
+			if len(result.Cursors) != 1 || result.Cursors[0] <= base {
+				t.Errorf("edit %d: cursors = %v, want the one change it wrote", i, result.Cursors)
+			}
+		}
+
+		var current models.Task
+		mustCall(t, r, http.MethodGet, fmt.Sprintf("/tasks/%d", task.ID), nil, &current)
+		if current.Title != "second" {
+			t.Errorf("title = %q, want second", current.Title)
+		}
+	})
+
+	t.Run("server edit", func(t *testing.T) {
+		// base is now behind the previous push, which was another client's as far as this one knows
+		results := push(edit("third"))
+		if results[0].Status != "conflict" {
+			t.Fatalf("status = %q, want conflict", results[0].Status)
+		}
+		if server, ok := results[0].Server.(map[string]interface{}); !ok || server["title"] != "second" {
+			t.Errorf("server = %v, want the task titled second", results[0].Server)
+		}
+	})
+}
+
+func TestPushChangesValidates(t *testing.T) {
+	r := testAPI(t)
+
+	var task models.Task
+	mustCall(t, r, http.MethodPost, "/tasks", gin.H{"title": "draft"}, &task)
+	var pull models.SyncPullResponse
+	mustCall(t, r, http.MethodGet, "/sync", nil, &pull)
+
+	var response models.SyncPushResponse
+	mustCall(t, r, http.MethodPost, "/sync", models.SyncPushRequest{Changes: []models.SyncEdit{
+		{Entity: "task", Operation: "create", Data: map[string]interface{}{"title": "new", "priority": "urgent"}},
+		{Entity: "task", ID: task.ID, Operation: "update", BaseCursor: pull.Cursor, Data: map[string]interface{}{"recurrence": "hourly"}},
+		{Entity: "event", Operation: "create", Data: map[string]interface{}{"title": "meeting", "task_id": 999}},
+	}}, &response)
+
+	want := []string{"Invalid priority", "Invalid recurrence", "Task not found"}
+	for i, result := range response.Results {
+		if result.Status != "error" || result.Error != want[i] {
+			t.Errorf("edit %d: %s %q, want error %q", i, result.Status, result.Error, want[i])
+		}
+	}
+}
+
+func TestPullChanges(t *testing.T) {
+	r := testAPI(t)
+
+	var tasks [3]models.Task
+	for i := range tasks {
+		mustCall(t, r, http.MethodPost, "/tasks", gin.H{"title": fmt.Sprintf("task %d", i)}, &tasks[i])
+	}
+	mustCall(t, r, http.MethodPatch, fmt.Sprintf("/tasks/%d", tasks[0].ID), gin.H{"title": "renamed"}, nil)
+	mustCall(t, r, http.MethodDelete, fmt.Sprintf("/tasks/%d", tasks[1].ID), nil, nil)
+
+	// tasks[2] is the oldest change left once the others were touched again
+	var page models.SyncPullResponse
+	mustCall(t, r, http.MethodGet, "/sync?limit=2", nil, &page)
+	if !page.HasMore || len(page.Changes) != 2 || page.Changes[0].ID != tasks[2].ID || page.Changes[1].ID != tasks[0].ID {
+		t.Fatalf("first page = %+v", page)
+	}
+	if data, ok := page.Changes[1].Data.(map[string]interface{}); !ok || data["title"] != "renamed" {
+		t.Errorf("renamed task data = %v", page.Changes[1].Data)
+	}
+	if page.Cursor != page.Changes[1].Cursor {
+		t.Errorf("cursor = %d, want the last change's %d", page.Cursor, page.Changes[1].Cursor)
+	}
+
+	var rest models.SyncPullResponse
+	mustCall(t, r, http.MethodGet, fmt.Sprintf("/sync?since=%d", page.Cursor), nil, &rest)
+	if rest.HasMore || len(rest.Changes) != 1 {
+		t.Fatalf("second page = %+v", rest)
+	}
+	if tombstone := rest.Changes[0]; tombstone.ID != tasks[1].ID || !tombstone.Deleted || tombstone.Data != nil {
+		t.Errorf("tombstone = %+v", tombstone)
+	}
+
+	var empty models.SyncPullResponse
+	mustCall(t, r, http.MethodGet, fmt.Sprintf("/sync?since=%d", rest.Cursor), nil, &empty)
+	if len(empty.Changes) != 0 || empty.Cursor != rest.Cursor {
+		t.Errorf("caught up: %+v, want no changes at cursor %d", empty, rest.Cursor)
+	}
+
+	for _, query := range []string{"since=-1", "since=abc", "limit=0", "limit=100000"} {
+		if w := call(t, r, http.MethodGet, "/sync?"+query, nil, nil); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", query, w.Code)
+		}
+	}
+}
+
+func TestPushChangesDelete(t *testing.T) {
+	r := testAPI(t)
+
+	push := func(edit models.SyncEdit) models.SyncPushResult {
+		t.Helper()
+		var response models.SyncPushResponse
+		mustCall(t, r, http.MethodPost, "/sync", models.SyncPushRequest{Changes: []models.SyncEdit{edit}}, &response)
+		return response.Results[0]
+	}
+
+	created := push(models.SyncEdit{ClientID: "tmp-1", Entity: "task", Operation: "create", Data: map[string]interface{}{"title": "offline"}})
+	if created.Status != "applied" || created.ClientID != "tmp-1" || created.ID == 0 || len(created.Cursors) != 1 {
+		t.Fatalf("create = %+v", created)
+	}
+	base := created.Cursors[0]
+
+	// Another client renames it, so deleting against the old cursor conflicts
+	mustCall(t, r, http.MethodPatch, fmt.Sprintf("/tasks/%d", created.ID), gin.H{"title": "online"}, nil)
+	del := models.SyncEdit{Entity: "task", ID: created.ID, Operation: "delete", BaseCursor: base}
+	if result := push(del); result.Status != "conflict" {
+		t.Fatalf("stale delete: %+v, want conflict", result)
+	}
+	if w := call(t, r, http.MethodGet, fmt.Sprintf("/tasks/%d", created.ID), nil, nil); w.Code != http.StatusOK {
+		t.Errorf("task after conflicting delete: status = %d, want 200", w.Code)
+	}
+
+	var pull models.SyncPullResponse
+	mustCall(t, r, http.MethodGet, "/sync", nil, &pull)
+	del.BaseCursor = pull.Cursor
+	if result := push(del); result.Status != "applied" || len(result.Cursors) == 0 {
+		t.Fatalf("delete: %+v", result)
+	}
+	if w := call(t, r, http.MethodGet, fmt.Sprintf("/tasks/%d", created.ID), nil, nil); w.Code != http.StatusNotFound {
+		t.Errorf("deleted task: status = %d, want 404", w.Code)
+	}
+
+	// Editing a record that's gone is a conflict with no server version
+	mustCall(t, r, http.MethodGet, "/sync", nil, &pull)
+	late := models.SyncEdit{Entity: "task", ID: created.ID, Operation: "update", BaseCursor: pull.Cursor, Data: map[string]interface{}{"title": "late"}}
+	if result := push(late); result.Status != "conflict" || result.Server != nil {
+		t.Errorf("update after delete: %+v, want a conflict without a server version", result)
+	}
+}
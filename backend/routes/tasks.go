@@ -1,90 +1,207 @@
 package routes
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
-	"strconv"
 	"time"
 
-	"github.com/rayzox/tickr-backend/database"
 	"github.com/rayzox/tickr-backend/models"
+	"gorm.io/gorm"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 )
 
-func RegisterTaskRoutes(r *gin.Engine) {
+func RegisterTaskRoutes(r gin.IRouter) {
 	r.GET("/tasks", GetTasks)
 	r.POST("/tasks", CreateTask)
+	r.POST("/tasks/bulk", BulkTasks)
+	r.GET("/tasks/:id", GetTask)
 	r.PUT("/tasks/:id", UpdateTask)
+	r.PATCH("/tasks/:id", PatchTask)
 	r.DELETE("/tasks/:id", DeleteTask)
+	r.GET("/tasks/:id/history", historyHandler("task"))
+}
+
+// Recurring tasks are repeated by the recurring_tasks job once completed
+var validRecurrences = map[string]bool{"": true, "daily": true, "weekly": true, "monthly": true}
+
+// bindTask binds a POST or PUT body onto task, with its due_date read by parseDueDate
+func bindTask(c *gin.Context, task *models.Task) error {
+	var body map[string]interface{}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		return err
+	}
+	if err := normalizeDueDate(body); err != nil {
+		return err
+	}
+	data, _ := json.Marshal(body)
+	return binding.JSON.BindBody(data, task)
+}
+
+// normalizeDueDate replaces a due_date string in a task's JSON with the time
+// it stands for, so it decodes into a Task. Empty means no due date.
+func normalizeDueDate(doc map[string]interface{}) error {
+	s, ok := doc["due_date"].(string)
+	if !ok {
+		return nil
+	}
+	if s == "" {
+		doc["due_date"] = time.Time{}
+		return nil
+	}
+	due, err := parseDueDate(s)
+	if err != nil {
+		return err
+	}
+	doc["due_date"] = due
+	return nil
+}
+
+// parseDueDate accepts RFC 3339, a time without a zone (UTC), or a bare date
+// which is due at 9:00
+func parseDueDate(s string) (time.Time, error) {
+	if due, err := time.Parse(time.RFC3339, s); err == nil {
+		return due, nil
+	}
+	if due, err := time.Parse("2006-01-02T15:04:05", s); err == nil {
+		return due, nil
+	}
+	if day, err := time.Parse("2006-01-02", s); err == nil {
+		return day.Add(9 * time.Hour), nil
+	}
+	return time.Time{}, fmt.Errorf("Invalid due_date %q, expected RFC 3339 or 2006-01-02", s)
 }
 
 func GetTasks(c *gin.Context) {
 	var tasks []models.Task
-	database.DB.Find(&tasks)
+	if err := findPage(c, db(c).Model(&models.Task{}).Order("id"), &tasks); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tasks"})
+		return
+	}
 	c.JSON(http.StatusOK, tasks)
 }
 
+func GetTask(c *gin.Context) {
+	id, ok := paramID(c, "task")
+	if !ok {
+		return
+	}
+
+	var task models.Task
+	if err := db(c).First(&task, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		return
+	}
+	respondWithETag(c, &task)
+}
+
 // tasks.go - Update CreateTask function
 func CreateTask(c *gin.Context) {
 	var task models.Task
-	if err := c.ShouldBindJSON(&task); err != nil {
+	if err := bindTask(c, &task); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Check if due_date is provided as a string and parse it
-	var requestData map[string]interface{}
-	if err := c.ShouldBindJSON(&requestData); err == nil {
-		if dueDateStr, ok := requestData["due_date"].(string); ok && dueDateStr != "" {
-			// Try parsing in different formats
-			if parsedDate, err := time.Parse(time.RFC3339, dueDateStr); err == nil {
-				task.DueDate = parsedDate
-			} else if parsedDate, err := time.Parse("2006-01-02T15:04:05", dueDateStr); err == nil {
-				task.DueDate = parsedDate
-			} else if parsedDate, err := time.Parse("2006-01-02", dueDateStr); err == nil {
-				// Set default time to 9:00 AM if only date is provided
-				task.DueDate = time.Date(parsedDate.Year(), parsedDate.Month(), parsedDate.Day(), 9, 0, 0, 0, parsedDate.Location())
-			}
-		}
+	if !checkRow(c, &task) {
+		return
 	}
 
-	if err := database.DB.Create(&task).Error; err != nil {
+	err := audited(c, func(tx *gorm.DB, audit *auditTrail) error {
+		if err := tx.Create(&task).Error; err != nil {
+			return err
+		}
+		return audit.record(tx, "task", task.ID, "create", nil, task)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create task"})
 		return
 	}
-
 	c.JSON(http.StatusOK, task)
 }
 
 func UpdateTask(c *gin.Context) {
+	id, ok := paramID(c, "task")
+	if !ok {
+		return
+	}
+
+	var task models.Task
+	if err := db(c).First(&task, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		return
+	}
+	if !checkIfMatch(c, &task) {
+		return
+	}
+	before := auditSnapshot(task)
+	loaded := task.Model
+	if err := bindTask(c, &task); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	task.Model = loaded // clients send whole records back, the IDs and timestamps are ours
+	if !checkRow(c, &task) {
+		return
+	}
+	if !saveIfUnchanged(c, "task", &task, loaded.UpdatedAt, before) {
+		return
+	}
+	c.Header("ETag", etagFor(&task))
+	c.JSON(http.StatusOK, task)
+}
+
+// PatchTask only changes the fields present in the body (JSON Merge Patch)
+func PatchTask(c *gin.Context) {
+	id, ok := paramID(c, "task")
+	if !ok {
+		return
+	}
+
 	var task models.Task
-	if err := database.DB.First(&task, c.Param("id")).Error; err != nil {
+	if err := db(c).First(&task, id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
 		return
 	}
-	c.BindJSON(&task)
-	database.DB.Save(&task)
+	if !patchRow(c, "task", &task) {
+		return
+	}
 	c.JSON(http.StatusOK, task)
 }
 
 func DeleteTask(c *gin.Context) {
-	idParam := c.Param("id")
-	id, err := strconv.Atoi(idParam) // Use Atoi instead of ParseUint
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+	id, ok := paramID(c, "task")
+	if !ok {
 		return
 	}
 
 	var task models.Task
-	if err := database.DB.First(&task, id).Error; err != nil {
+	if err := db(c).First(&task, id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
 		return
 	}
 
-	if err := database.DB.Delete(&task).Error; err != nil {
+	// ?with_events=true trashes the task's scheduled events too instead of keeping them unlinked
+	withEvents := c.Query("with_events") == "true"
+
+	response := models.Message{Message: "Task deleted successfully"}
+	err := audited(c, func(tx *gorm.DB, audit *auditTrail) error {
+		steps, err := deleteTask(tx, &task, withEvents)
+		if err != nil {
+			return err
+		}
+		response.UndoToken, response.UndoExpiresAt, err = issueUndo(tx, "task.delete", steps)
+		if err != nil {
+			return err
+		}
+		return audit.record(tx, "task", task.ID, "delete", task, nil)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete task"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Task deleted successfully"})
+	c.JSON(http.StatusOK, response)
 }
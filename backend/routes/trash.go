@@ -0,0 +1,268 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rayzox/tickr-backend/models"
+	"github.com/rayzox/tickr-backend/trash"
+	"gorm.io/gorm"
+)
+
+func RegisterTrashRoutes(r gin.IRouter) {
+	trash := r.Group("/trash")
+	{
+		trash.GET("", GetTrash)
+		trash.DELETE("", EmptyTrash)
+		trash.POST("/:type/:id/restore", RestoreTrashItem)
+		trash.DELETE("/:type/:id", PurgeTrashItem)
+	}
+}
+
+func GetTrash(c *gin.Context) {
+	kind := c.Query("type")
+	if kind != "" && models.Entities[kind] == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown trash type"})
+		return
+	}
+
+	items, err := listTrash(c, kind)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch trash"})
+		return
+	}
+
+	c.JSON(http.StatusOK, items)
+}
+
+func RestoreTrashItem(c *gin.Context) {
+	kind, id, ok := trashParams(c)
+	if !ok {
+		return
+	}
+
+	err := audited(c, func(tx *gorm.DB, audit *auditTrail) error {
+		if err := restoreEntity(tx, kind, id, nil); err != nil {
+			return err
+		}
+		return audit.record(tx, kind, id, "restore", nil, nil)
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found in trash"})
+		return
+	}
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		// e.g. an unfinished focus session while another one is running
+		c.JSON(http.StatusConflict, gin.H{"error": "Item conflicts with an existing one"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore item"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.RestoreResult{Message: "Item restored", Type: kind, ID: id})
+}
+
+func PurgeTrashItem(c *gin.Context) {
+	kind, id, ok := trashParams(c)
+	if !ok {
+		return
+	}
+
+	err := audited(c, func(tx *gorm.DB, audit *auditTrail) error {
+		result := tx.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).Delete(models.Entities[kind]())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return audit.record(tx, kind, id, "purge", nil, nil)
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found in trash"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to purge item"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Item permanently deleted"})
+}
+
+func EmptyTrash(c *gin.Context) {
+	purged, err := trash.Purge(c.Request.Context(), time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to empty trash"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.PurgeResult{Message: "Trash emptied", Purged: purged})
+}
+
+// trashParams validates the :type and :id segments, writing the error response itself
+func trashParams(c *gin.Context) (string, uint, bool) {
+	kind := c.Param("type")
+	if models.Entities[kind] == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown trash type"})
+		return "", 0, false
+	}
+
+	id, ok := paramID(c, kind)
+	return kind, id, ok
+}
+
+func listTrash(c *gin.Context, kind string) ([]models.TrashItem, error) {
+	items := []models.TrashItem{}
+	deleted := db(c).Unscoped().Where("deleted_at IS NOT NULL").Session(&gorm.Session{})
+
+	if kind == "" || kind == "task" {
+		var tasks []models.Task
+		if err := deleted.Find(&tasks).Error; err != nil {
+			return nil, err
+		}
+		for _, t := range tasks {
+			items = append(items, trashItem("task", t.ID, t.Title, t.DeletedAt))
+		}
+	}
+
+	if kind == "" || kind == "habit" {
+		var habits []models.Habit
+		if err := deleted.Find(&habits).Error; err != nil {
+			return nil, err
+		}
+		for _, h := range habits {
+			items = append(items, trashItem("habit", h.ID, h.Name, h.DeletedAt))
+		}
+	}
+
+	if kind == "" || kind == "event" {
+		var events []models.Event
+		if err := deleted.Find(&events).Error; err != nil {
+			return nil, err
+		}
+		for _, e := range events {
+			items = append(items, trashItem("event", e.ID, e.Title, e.DeletedAt))
+		}
+	}
+
+	if kind == "" || kind == "pomodoro" {
+		var sessions []models.PomodoroSession
+		if err := deleted.Find(&sessions).Error; err != nil {
+			return nil, err
+		}
+		for _, s := range sessions {
+			title := fmt.Sprintf("%s session (%d min)", s.Phase, s.Duration)
+			items = append(items, trashItem("pomodoro", s.ID, title, s.DeletedAt))
+		}
+	}
+
+	if kind == "" || kind == "focus" {
+		var sessions []models.FocusSession
+		if err := deleted.Preload("Task", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).Find(&sessions).Error; err != nil {
+			return nil, err
+		}
+		for _, s := range sessions {
+			title := "Focus session"
+			if s.Task.Title != "" {
+				title += ": " + s.Task.Title
+			}
+			items = append(items, trashItem("focus", s.ID, title, s.DeletedAt))
+		}
+	}
+
+	// Most recently deleted first
+	sort.Slice(items, func(i, j int) bool { return items[i].DeletedAt.After(items[j].DeletedAt) })
+
+	return items, nil
+}
+
+func trashItem(kind string, id uint, title string, deletedAt gorm.DeletedAt) models.TrashItem {
+	item := models.TrashItem{Type: kind, ID: id, Title: title, DeletedAt: deletedAt.Time}
+	if retention := trash.Retention(); retention > 0 {
+		purgeAt := deletedAt.Time.Add(retention)
+		item.PurgeAt = &purgeAt
+	}
+	return item
+}
+
+// restoreEntity un-deletes a trashed row and re-links the relationships that
+// pointed at it while it was gone. With deletedAt, only a row deleted at that
+// exact time comes back, otherwise it's gorm.ErrRecordNotFound.
+func restoreEntity(tx *gorm.DB, kind string, id uint, deletedAtWas *time.Time) error {
+	model := models.Entities[kind]()
+	if err := tx.Unscoped().Where("deleted_at IS NOT NULL").First(model, id).Error; err != nil {
+		return err
+	}
+	deletedAt := reflect.ValueOf(model).Elem().FieldByName("DeletedAt").Interface().(gorm.DeletedAt).Time
+	if deletedAtWas != nil && !deletedAt.Equal(*deletedAtWas) {
+		return gorm.ErrRecordNotFound // restored and deleted again since
+	}
+
+	if err := tx.Unscoped().Model(model).Update("deleted_at", nil).Error; err != nil {
+		return err
+	}
+
+	switch m := model.(type) {
+	case *models.Task:
+		// Focus sessions, and events if asked for, were trashed along with the task
+		for _, related := range []interface{}{&models.FocusSession{}, &models.Event{}} {
+			if err := tx.Unscoped().Model(related).
+				Where("task_id = ? AND deleted_at = ?", m.ID, deletedAt).
+				Update("deleted_at", nil).Error; err != nil {
+				return err
+			}
+		}
+		return relinkTask(tx, m, deletedAt)
+	case *models.Event:
+		// Give the task back its calendar slot if it hasn't been rescheduled meanwhile
+		if m.TaskID != nil {
+			return tx.Model(&models.Task{}).
+				Where("id = ? AND calendar_event_id IS NULL", *m.TaskID).
+				Update("calendar_event_id", m.ID).Error
+		}
+	case *models.Habit:
+		// Bring back the reminders that were trashed along with the habit
+		return tx.Unscoped().Model(&models.Event{}).
+			Where("habit_id = ? AND deleted_at = ?", m.ID, deletedAt).
+			Update("deleted_at", nil).Error
+	}
+
+	return nil
+}
+
+func relinkTask(tx *gorm.DB, task *models.Task, deletedAt time.Time) error {
+	if task.CalendarEventID != nil {
+		var event models.Event
+		err := tx.Unscoped().First(&event, *task.CalendarEventID).Error
+		if err == nil {
+			// The event went to the trash with the task, so it comes back with it
+			if event.DeletedAt.Valid && !event.DeletedAt.Time.Before(deletedAt) {
+				return tx.Unscoped().Model(&event).Update("deleted_at", nil).Error
+			}
+			if !event.DeletedAt.Valid {
+				return nil
+			}
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+	}
+
+	// The old event is gone, fall back to any live event scheduled for this task
+	var event models.Event
+	err := tx.Where("task_id = ?", task.ID).Order("event_date DESC").First(&event).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return tx.Model(task).Update("calendar_event_id", nil).Error
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Model(task).Update("calendar_event_id", event.ID).Error
+}
@@ -0,0 +1,174 @@
+package routes
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rayzox/tickr-backend/config"
+	"github.com/rayzox/tickr-backend/models"
+	"gorm.io/gorm"
+)
+
+var errUndoConflict = errors.New("undo conflicts with later changes")
+
+func RegisterUndoRoutes(r gin.IRouter) {
+	r.POST("/undo/:token", Undo)
+}
+
+// UndoWindow is how long an undo token stays valid, undo_window in the config
+func UndoWindow() time.Duration {
+	return config.Get().UndoWindow.Duration
+}
+
+// issueUndo stores the steps that reverse an operation and returns the token for
+// the response body. It runs in the operation's transaction, so there's never an
+// operation without its token or a token for one that was rolled back.
+func issueUndo(tx *gorm.DB, operation string, steps []models.UndoStep) (string, *time.Time, error) {
+	if len(steps) == 0 {
+		return "", nil, nil
+	}
+
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, err
+	}
+
+	now := time.Now()
+	token := models.UndoToken{
+		Token:     hex.EncodeToString(buf),
+		Operation: operation,
+		Steps:     steps,
+		ExpiresAt: now.Add(UndoWindow()),
+	}
+	if err := tx.Create(&token).Error; err != nil {
+		return "", nil, err
+	}
+
+	// Tokens are useless once expired, so keep the table small while we're here
+	tx.Where("expires_at < ?", now.Add(-24*time.Hour)).Delete(&models.UndoToken{})
+
+	return token.Token, &token.ExpiresAt, nil
+}
+
+func Undo(c *gin.Context) {
+	var token models.UndoToken
+	if err := db(c).Where("token = ?", c.Param("token")).First(&token).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Undo token not found"})
+		return
+	}
+
+	if token.UsedAt != nil {
+		c.JSON(http.StatusGone, gin.H{"error": "Operation has already been undone"})
+		return
+	}
+	if time.Now().After(token.ExpiresAt) {
+		c.JSON(http.StatusGone, gin.H{"error": "Undo token has expired"})
+		return
+	}
+
+	err := audited(c, func(tx *gorm.DB, audit *auditTrail) error {
+		// Claim the token first so two concurrent undos can't both apply
+		now := time.Now()
+		claim := tx.Model(&models.UndoToken{}).Where("id = ? AND used_at IS NULL", token.ID).Update("used_at", now)
+		if claim.Error != nil {
+			return claim.Error
+		}
+		if claim.RowsAffected == 0 {
+			return errUndoConflict
+		}
+
+		// Reverse in the opposite order the operation was applied
+		for i := len(token.Steps) - 1; i >= 0; i-- {
+			step := token.Steps[i]
+			newModel, ok := models.Entities[step.Entity]
+			if !ok {
+				return errUndoConflict
+			}
+
+			if step.Restore {
+				if err := restoreEntity(tx, step.Entity, step.ID, step.DeletedAt); err != nil {
+					if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, gorm.ErrDuplicatedKey) {
+						return errUndoConflict // purged or restored in the meantime
+					}
+					return err
+				}
+				if err := audit.record(tx, step.Entity, step.ID, "restore", nil, nil); err != nil {
+					return err
+				}
+			}
+
+			if len(step.Previous) > 0 {
+				row := newModel()
+				if err := tx.First(row, step.ID).Error; err != nil {
+					if errors.Is(err, gorm.ErrRecordNotFound) {
+						return errUndoConflict
+					}
+					return err
+				}
+				before := auditSnapshot(row)
+
+				values, err := typedColumns(tx, row, step.Previous)
+				if err != nil {
+					return err
+				}
+				if err := tx.Model(row).Updates(values).Error; err != nil {
+					if errors.Is(err, gorm.ErrForeignKeyViolated) {
+						return errUndoConflict // points back at a row that was purged since
+					}
+					return err
+				}
+				if err := audit.record(tx, step.Entity, step.ID, "update", before, row); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+	if errors.Is(err, errUndoConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Operation can no longer be undone"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to undo operation"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.UndoResult{Message: "Operation undone", Operation: token.Operation, Reverted: len(token.Steps)})
+}
+
+// typedColumns converts values that went through JSON back into the Go types of
+// the model's columns, so times are written in the same format gorm uses
+func typedColumns(tx *gorm.DB, model interface{}, values map[string]interface{}) (map[string]interface{}, error) {
+	stmt := &gorm.Statement{DB: tx}
+	if err := stmt.Parse(model); err != nil {
+		return nil, err
+	}
+
+	typed := make(map[string]interface{}, len(values))
+	for column, value := range values {
+		field := stmt.Schema.LookUpField(column)
+		if field == nil {
+			continue
+		}
+
+		if s, ok := value.(string); ok {
+			switch field.FieldType.String() {
+			case "time.Time", "*time.Time":
+				t, err := time.Parse(time.RFC3339Nano, s)
+				if err != nil {
+					return nil, err
+				}
+				typed[field.DBName] = t
+				continue
+			}
+		}
+		typed[field.DBName] = value
+	}
+
+	return typed, nil
+}
@@ -0,0 +1,93 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rayzox/tickr-backend/models"
+)
+
+func TestUndoDelete(t *testing.T) {
+	r := testAPI(t)
+
+	setup := func(t *testing.T) (models.Task, models.Event) {
+		t.Helper()
+		var task models.Task
+		mustCall(t, r, http.MethodPost, "/tasks", gin.H{"title": "write report"}, &task)
+		var event models.Event
+		mustCall(t, r, http.MethodPost, "/calendar/events", gin.H{"title": "work on it", "task_id": task.ID}, &event)
+		return task, event
+	}
+	status := func(path string) int {
+		return call(t, r, http.MethodGet, path, nil, nil).Code
+	}
+
+	t.Run("cascade", func(t *testing.T) {
+		task, event := setup(t)
+		taskPath, eventPath := fmt.Sprintf("/tasks/%d", task.ID), fmt.Sprintf("/calendar/events/%d", event.ID)
+
+		var deleted models.Message
+		mustCall(t, r, http.MethodDelete, taskPath+"?with_events=true", nil, &deleted)
+		if deleted.UndoToken == "" {
+			t.Fatal("no undo token")
+		}
+		if status(taskPath) != http.StatusNotFound || status(eventPath) != http.StatusNotFound {
+			t.Fatal("task or event still there after the delete")
+		}
+
+		var undone models.UndoResult
+		mustCall(t, r, http.MethodPost, "/undo/"+deleted.UndoToken, nil, &undone)
+		if undone.Operation != "task.delete" {
+			t.Errorf("operation = %q", undone.Operation)
+		}
+		var restored models.Event
+		mustCall(t, r, http.MethodGet, eventPath, nil, &restored)
+		if restored.TaskID == nil || *restored.TaskID != task.ID {
+			t.Errorf("event task_id = %v, want %d", restored.TaskID, task.ID)
+		}
+		if status(taskPath) != http.StatusOK {
+			t.Error("task wasn't restored")
+		}
+
+		if code := call(t, r, http.MethodPost, "/undo/"+deleted.UndoToken, nil, nil).Code; code != http.StatusGone {
+			t.Errorf("second undo: status = %d, want 410", code)
+		}
+	})
+
+	t.Run("detached", func(t *testing.T) {
+		task, event := setup(t)
+		eventPath := fmt.Sprintf("/calendar/events/%d", event.ID)
+
+		var deleted models.Message
+		mustCall(t, r, http.MethodDelete, fmt.Sprintf("/tasks/%d", task.ID), nil, &deleted)
+		var kept models.Event
+		mustCall(t, r, http.MethodGet, eventPath, nil, &kept)
+		if kept.TaskID != nil {
+			t.Fatalf("event task_id = %d after the delete, want nil", *kept.TaskID)
+		}
+
+		mustCall(t, r, http.MethodPost, "/undo/"+deleted.UndoToken, nil, nil)
+		mustCall(t, r, http.MethodGet, eventPath, nil, &kept)
+		if kept.TaskID == nil || *kept.TaskID != task.ID {
+			t.Errorf("event task_id = %v after the undo, want %d", kept.TaskID, task.ID)
+		}
+	})
+
+	t.Run("purged", func(t *testing.T) {
+		task, _ := setup(t)
+
+		var deleted models.Message
+		mustCall(t, r, http.MethodDelete, fmt.Sprintf("/tasks/%d", task.ID), nil, &deleted)
+		mustCall(t, r, http.MethodDelete, fmt.Sprintf("/trash/task/%d", task.ID), nil, nil)
+
+		if code := call(t, r, http.MethodPost, "/undo/"+deleted.UndoToken, nil, nil).Code; code != http.StatusConflict {
+			t.Errorf("undo after purge: status = %d, want 409", code)
+		}
+	})
+
+	if code := call(t, r, http.MethodPost, "/undo/nope", nil, nil).Code; code != http.StatusNotFound {
+		t.Errorf("unknown token: status = %d, want 404", code)
+	}
+}
@@ -0,0 +1,70 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rayzox/tickr-backend/models"
+	"gorm.io/gorm"
+)
+
+// invalidRow is why validateRow refused a row, meant for the client
+type invalidRow string
+
+func (e invalidRow) Error() string { return string(e) }
+
+// validateRow checks what a model's field types can't: the allowed values, and
+// that references point at live rows. POST, PUT, PATCH, bulk and sync all go
+// through it, so whatever one of them accepts the others accept too. Errors
+// other than invalidRow come from the database.
+func validateRow(tx *gorm.DB, row interface{}) error {
+	switch r := row.(type) {
+	case *models.Task:
+		if !validRecurrences[r.Recurrence] {
+			return invalidRow("Invalid recurrence")
+		}
+		if r.Priority != "" && !validPriorities[r.Priority] {
+			return invalidRow("Invalid priority")
+		}
+	case *models.Habit:
+		if !validTimezone(r.Timezone) {
+			return invalidRow("Invalid timezone")
+		}
+	case *models.Event:
+		if r.Priority != "" && !validPriorities[r.Priority] {
+			return invalidRow("Invalid priority")
+		}
+		if err := checkRowReference(tx, &models.Task{}, r.TaskID, "Task not found"); err != nil {
+			return err
+		}
+		return checkRowReference(tx, &models.Habit{}, r.HabitID, "Habit not found")
+	case *models.PomodoroSession:
+		return checkRowReference(tx, &models.Task{}, r.TaskID, "Task not found")
+	}
+	return nil
+}
+
+func checkRowReference(tx *gorm.DB, model interface{}, id *uint, message string) error {
+	err := checkReference(tx, model, id)
+	if errors.Is(err, errReferenceNotFound) {
+		return invalidRow(message)
+	}
+	return err
+}
+
+// checkRow runs validateRow for a handler, answering 400 for an invalid row
+// and 500 when the check itself failed
+func checkRow(c *gin.Context, row interface{}) bool {
+	err := validateRow(db(c), row)
+	var invalid invalidRow
+	switch {
+	case err == nil:
+		return true
+	case errors.As(err, &invalid):
+		c.JSON(http.StatusBadRequest, gin.H{"error": invalid.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check references"})
+	}
+	return false
+}
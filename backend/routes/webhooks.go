@@ -0,0 +1,211 @@
+package routes
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rayzox/tickr-backend/models"
+	"github.com/rayzox/tickr-backend/webhooks"
+)
+
+func RegisterWebhookRoutes(r gin.IRouter) {
+	hooks := r.Group("/webhooks")
+	{
+		hooks.GET("", GetWebhooks)
+		hooks.POST("", CreateWebhook)
+		hooks.GET("/:id", GetWebhook)
+		hooks.PUT("/:id", UpdateWebhook)
+		hooks.DELETE("/:id", DeleteWebhook)
+		hooks.GET("/:id/deliveries", GetWebhookDeliveries)
+		hooks.POST("/:id/test", TestWebhook)
+	}
+}
+
+func GetWebhooks(c *gin.Context) {
+	hooks := []models.Webhook{}
+	if err := db(c).Order("id").Find(&hooks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch webhooks"})
+		return
+	}
+	c.JSON(http.StatusOK, models.WebhookList{Webhooks: hooks, EventTypes: webhooks.EventTypes})
+}
+
+func GetWebhook(c *gin.Context) {
+	id, ok := paramID(c, "webhook")
+	if !ok {
+		return
+	}
+
+	var hook models.Webhook
+	if err := db(c).First(&hook, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+		return
+	}
+	c.JSON(http.StatusOK, hook)
+}
+
+func CreateWebhook(c *gin.Context) {
+	var request models.WebhookRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	hook := models.Webhook{URL: request.URL, Events: request.Events, Secret: request.Secret, Active: true}
+	if request.Active != nil {
+		hook.Active = *request.Active
+	}
+	if !validWebhook(c, &hook) {
+		return
+	}
+
+	if hook.Secret == "" {
+		buf := make([]byte, 32)
+		if _, err := rand.Read(buf); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate secret"})
+			return
+		}
+		hook.Secret = hex.EncodeToString(buf)
+	}
+
+	if err := db(c).Create(&hook).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create webhook"})
+		return
+	}
+	c.JSON(http.StatusOK, models.WebhookCreated{Webhook: hook, Secret: hook.Secret})
+}
+
+func UpdateWebhook(c *gin.Context) {
+	id, ok := paramID(c, "webhook")
+	if !ok {
+		return
+	}
+
+	var hook models.Webhook
+	if err := db(c).First(&hook, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+		return
+	}
+
+	var request models.WebhookRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	hook.URL = request.URL
+	if request.Events != nil {
+		hook.Events = request.Events
+	}
+	if request.Secret != "" {
+		hook.Secret = request.Secret
+	}
+	if request.Active != nil {
+		hook.Active = *request.Active
+	}
+	if !validWebhook(c, &hook) {
+		return
+	}
+
+	if err := db(c).Save(&hook).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update webhook"})
+		return
+	}
+	c.JSON(http.StatusOK, hook)
+}
+
+func DeleteWebhook(c *gin.Context) {
+	id, ok := paramID(c, "webhook")
+	if !ok {
+		return
+	}
+
+	result := db(c).Delete(&models.Webhook{}, id)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete webhook"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook deleted"})
+}
+
+// GetWebhookDeliveries is the delivery log, newest first
+func GetWebhookDeliveries(c *gin.Context) {
+	id, ok := paramID(c, "webhook")
+	if !ok {
+		return
+	}
+
+	// the legacy route only has the latest 100
+	query := db(c).Model(&models.WebhookDelivery{}).Where("webhook_id = ?", id).Order("id DESC")
+	if apiVersion(c) == 0 {
+		query = query.Limit(100)
+	}
+	deliveries := []models.WebhookDelivery{}
+	if err := findPage(c, query, &deliveries); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch deliveries"})
+		return
+	}
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// TestWebhook sends a ping event right away and returns how it went
+func TestWebhook(c *gin.Context) {
+	id, ok := paramID(c, "webhook")
+	if !ok {
+		return
+	}
+
+	var hook models.Webhook
+	if err := db(c).First(&hook, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+		return
+	}
+
+	delivery, err := webhooks.SendTest(c.Request.Context(), hook)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send test event"})
+		return
+	}
+	c.JSON(http.StatusOK, delivery)
+}
+
+// validWebhook checks the URL and event types, writing the error response itself
+func validWebhook(c *gin.Context, hook *models.Webhook) bool {
+	u, err := url.Parse(hook.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "url must be an http(s) URL"})
+		return false
+	}
+	for _, event := range hook.Events {
+		if !webhooks.ValidEvent(event) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown event type " + event})
+			return false
+		}
+	}
+	return true
+}
+
+// emitWebhookEvent turns a change recorded in the audit log into the webhook
+// event it stands for, if any
+func emitWebhookEvent(entityType, action string, changes map[string]models.FieldChange, after map[string]interface{}) {
+	var event string
+	switch {
+	case entityType == "task" && action == "update" && changes["completed"].New == true:
+		event = "task.completed"
+	case entityType == "habit" && action == "update" && changes["completed_today"].New == true:
+		event = "habit.checked"
+	case entityType == "pomodoro" && action == "create":
+		event = "pomodoro.finished"
+	case entityType == "focus" && action == "update" && changes["end_time"].Old == nil && changes["end_time"].New != nil && after["completed"] == true:
+		event = "focus.completed"
+	default:
+		return
+	}
+
+	webhooks.Emit(event, after)
+}
@@ -0,0 +1,42 @@
+// Package trash permanently deletes soft deleted rows, for the trash
+// endpoints and the purge job alike
+package trash
+
+import (
+	"context"
+	"time"
+
+	"github.com/rayzox/tickr-backend/config"
+	"github.com/rayzox/tickr-backend/database"
+	"github.com/rayzox/tickr-backend/models"
+)
+
+// Retention is how long deleted items are kept before being purged,
+// trash_retention_days in the config (0 keeps them forever)
+func Retention() time.Duration {
+	return time.Duration(config.Get().TrashRetentionDays) * 24 * time.Hour
+}
+
+// Purge permanently deletes every trashed row deleted before cutoff
+func Purge(ctx context.Context, cutoff time.Time) (int64, error) {
+	db := database.DB.WithContext(ctx)
+
+	var purged int64
+	for _, newModel := range models.Entities {
+		result := db.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).Delete(newModel())
+		if result.Error != nil {
+			return purged, result.Error
+		}
+		purged += result.RowsAffected
+	}
+	return purged, nil
+}
+
+// PurgeExpired removes items that have been in the trash longer than the retention period
+func PurgeExpired(ctx context.Context) (int64, error) {
+	retention := Retention()
+	if retention == 0 {
+		return 0, nil
+	}
+	return Purge(ctx, time.Now().Add(-retention))
+}
@@ -0,0 +1,55 @@
+// Package web serves the frontend from the binary. `npm run build` in
+// frontend/ writes it to web/dist, which is embedded at compile time.
+package web
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed all:dist
+var dist embed.FS
+
+// Handler serves the frontend to GET requests no route matched. Paths that
+// aren't a file get index.html so the app can route them itself, unless they
+// look like a file or an API call, those fall through to the next handler.
+func Handler() gin.HandlerFunc {
+	files, _ := fs.Sub(dist, "dist")
+
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			return
+		}
+		urlPath := c.Request.URL.Path
+		if strings.HasPrefix(urlPath, "/api/") {
+			return
+		}
+
+		name := strings.TrimPrefix(path.Clean(urlPath), "/")
+		if info, err := fs.Stat(files, name); err != nil || info.IsDir() {
+			if path.Ext(name) != "" {
+				return
+			}
+			name = "index.html"
+		}
+		if _, err := fs.Stat(files, name); err != nil {
+			c.String(http.StatusNotFound, "The frontend isn't built, run npm run build in frontend/")
+			c.Abort()
+			return
+		}
+
+		// Vite puts a content hash in the names of everything under assets/
+		if strings.HasPrefix(name, "assets/") {
+			c.Header("Cache-Control", "public, max-age=31536000, immutable")
+		} else {
+			c.Header("Cache-Control", "no-cache")
+		}
+		http.ServeFileFS(c.Writer, c.Request, files, name)
+		c.Abort()
+	}
+}
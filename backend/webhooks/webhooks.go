@@ -0,0 +1,316 @@
+// Package webhooks sends Tickr events to the URLs users subscribed, signing
+// each payload and retrying failed deliveries with exponential backoff.
+//
+// Receivers verify a delivery by computing HMAC-SHA256 over
+// "<X-Tickr-Timestamp>.<body>" with the webhook's secret and comparing it to
+// the hex digest in X-Tickr-Signature (prefixed with "sha256=").
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rayzox/tickr-backend/database"
+	"github.com/rayzox/tickr-backend/metrics"
+	"github.com/rayzox/tickr-backend/models"
+	"gorm.io/gorm"
+)
+
+// Events webhooks can subscribe to. "ping" is only sent by the test endpoint.
+var EventTypes = []string{"task.completed", "habit.checked", "pomodoro.finished", "focus.completed"}
+
+var (
+	// MaxAttempts is how many times a delivery is tried before it's marked failed
+	MaxAttempts = 8
+	// RetryDelay is the wait before the first retry, doubled after each failure up to an hour
+	RetryDelay = 30 * time.Second
+	// Concurrency is how many webhooks are delivered to at once. Deliveries to
+	// the same webhook still go out one at a time, in order.
+	Concurrency = 4
+	// TickBudget caps how long one round of deliveries may take, so a slow
+	// receiver can't hold up the rest for long. What's left waits for the next round.
+	TickBudget = 30 * time.Second
+
+	client = &http.Client{Timeout: 10 * time.Second}
+	wake   = make(chan struct{}, 1)
+)
+
+// Payload is the JSON body of every delivery
+type Payload struct {
+	DeliveryID uint        `json:"delivery_id"`
+	Event      string      `json:"event"`
+	CreatedAt  time.Time   `json:"created_at"`
+	Data       interface{} `json:"data"`
+}
+
+// ValidEvent reports whether webhooks can subscribe to event
+func ValidEvent(event string) bool {
+	for _, e := range EventTypes {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Emit queues event for every active webhook subscribed to it. Failing to
+// queue is logged, it never fails what triggered the event.
+func Emit(event string, data interface{}) {
+	var hooks []models.Webhook
+	if err := database.DB.Where("active = ?", true).Find(&hooks).Error; err != nil {
+		slog.Error("Failed to load webhooks", "event", event, "error", err)
+		return
+	}
+
+	queued := false
+	for _, hook := range hooks {
+		if !subscribed(hook, event) {
+			continue
+		}
+		if _, err := queue(hook, event, data); err != nil {
+			slog.Error("Failed to queue webhook delivery", "event", event, "webhook_id", hook.ID, "error", err)
+			continue
+		}
+		queued = true
+	}
+
+	if queued {
+		select {
+		case wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// SendTest queues a ping to hook and delivers it right away, so the result can
+// be shown to whoever is setting the webhook up. A failed ping is retried like
+// any other delivery.
+func SendTest(ctx context.Context, hook models.Webhook) (*models.WebhookDelivery, error) {
+	delivery, err := queue(hook, "ping", map[string]interface{}{"webhook_id": hook.ID, "url": hook.URL})
+	if err != nil {
+		return nil, err
+	}
+	if err := attempt(ctx, delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+func subscribed(hook models.Webhook, event string) bool {
+	if len(hook.Events) == 0 {
+		return true
+	}
+	for _, e := range hook.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+func queue(hook models.Webhook, event string, data interface{}) (*models.WebhookDelivery, error) {
+	now := time.Now()
+	delivery := &models.WebhookDelivery{WebhookID: hook.ID, Event: event, Status: "pending", NextAttemptAt: &now}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// The payload carries the delivery ID, so it's written once the row exists
+		if err := tx.Create(delivery).Error; err != nil {
+			return err
+		}
+		body, err := json.Marshal(Payload{DeliveryID: delivery.ID, Event: event, CreatedAt: now, Data: data})
+		if err != nil {
+			return err
+		}
+		delivery.Payload = string(body)
+		return tx.Model(delivery).Update("payload", delivery.Payload).Error
+	})
+	return delivery, err
+}
+
+// Start delivers queued events in the background until ctx is cancelled. The
+// returned channel is closed once the worker has stopped.
+func Start(ctx context.Context) <-chan struct{} {
+	done := make(chan struct{})
+
+	// Deliveries interrupted by a crash or shutdown go out again
+	database.DB.Model(&models.WebhookDelivery{}).Where("status = ?", "sending").Update("status", "pending")
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(5 * time.Second)
+		defer ticker.Stop()
+
+		for {
+			deliverDue(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-wake:
+			}
+		}
+	}()
+
+	return done
+}
+
+// deliverDue sends the deliveries whose attempt is due, a few webhooks at a time
+func deliverDue(ctx context.Context) {
+	var due []models.WebhookDelivery
+	if err := database.DB.Where("status = ? AND next_attempt_at <= ?", "pending", time.Now()).
+		Order("next_attempt_at").Limit(50).Find(&due).Error; err != nil {
+		slog.Error("Failed to load webhook deliveries", "error", err)
+		return
+	}
+
+	var order []uint
+	byHook := map[uint][]*models.WebhookDelivery{}
+	for i := range due {
+		id := due[i].WebhookID
+		if _, ok := byHook[id]; !ok {
+			order = append(order, id)
+		}
+		byHook[id] = append(byHook[id], &due[i])
+	}
+
+	deadline := time.Now().Add(TickBudget)
+	slots := make(chan struct{}, Concurrency)
+	var wg sync.WaitGroup
+	for _, id := range order {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(deliveries []*models.WebhookDelivery) {
+			defer func() { <-slots; wg.Done() }()
+			for _, delivery := range deliveries {
+				if ctx.Err() != nil || time.Now().After(deadline) {
+					return
+				}
+				if err := attempt(ctx, delivery); err != nil {
+					slog.Warn("Failed to deliver webhook", "delivery_id", delivery.ID, "webhook_id", delivery.WebhookID, "error", err)
+				}
+			}
+		}(byHook[id])
+	}
+	wg.Wait()
+}
+
+// attempt makes one delivery attempt and schedules the retry if it failed
+func attempt(ctx context.Context, delivery *models.WebhookDelivery) error {
+	// Claim the delivery so the worker and the test endpoint can't both send it
+	claim := database.DB.Model(&models.WebhookDelivery{}).
+		Where("id = ? AND status = ?", delivery.ID, "pending").
+		Update("status", "sending")
+	if claim.Error != nil {
+		return claim.Error
+	}
+	if claim.RowsAffected == 0 {
+		return nil
+	}
+
+	var hook models.Webhook
+	if err := database.DB.First(&hook, delivery.WebhookID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return finish(delivery, "failed", models.WebhookAttempt{At: time.Now(), Error: "webhook was deleted"})
+		}
+		return err
+	}
+
+	result := post(ctx, hook, delivery)
+	if ctx.Err() != nil {
+		// Shutting down, send it again on the next start
+		return database.DB.Model(delivery).Update("status", "pending").Error
+	}
+
+	if result.StatusCode >= 200 && result.StatusCode < 300 {
+		metrics.WebhookAttempts.Inc("succeeded")
+		return finish(delivery, "succeeded", result)
+	}
+	if len(delivery.Attempts)+1 >= MaxAttempts {
+		metrics.WebhookAttempts.Inc("failed")
+		return finish(delivery, "failed", result)
+	}
+	metrics.WebhookAttempts.Inc("retrying")
+	return finish(delivery, "pending", result)
+}
+
+func post(ctx context.Context, hook models.Webhook, delivery *models.WebhookDelivery) models.WebhookAttempt {
+	started := time.Now()
+	result := models.WebhookAttempt{At: started}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewBufferString(delivery.Payload))
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	timestamp := strconv.FormatInt(started.Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Tickr-Webhooks/1.0")
+	req.Header.Set("X-Tickr-Event", delivery.Event)
+	req.Header.Set("X-Tickr-Delivery", strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set("X-Tickr-Timestamp", timestamp)
+	req.Header.Set("X-Tickr-Signature", "sha256="+Sign(hook.Secret, timestamp, []byte(delivery.Payload)))
+
+	resp, err := client.Do(req)
+	result.DurationMs = time.Since(started).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	result.StatusCode = resp.StatusCode
+	result.Response = string(body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		result.Error = fmt.Sprintf("receiver responded with %d", resp.StatusCode)
+	}
+	return result
+}
+
+func finish(delivery *models.WebhookDelivery, status string, result models.WebhookAttempt) error {
+	delivery.Status = status
+	delivery.Attempts = append(delivery.Attempts, result)
+	delivery.NextAttemptAt = nil
+	if status == "pending" {
+		next := time.Now().Add(retryDelay(len(delivery.Attempts)))
+		delivery.NextAttemptAt = &next
+	}
+
+	return database.DB.Model(delivery).Select("status", "attempts", "next_attempt_at").Updates(delivery).Error
+}
+
+// retryDelay doubles RetryDelay for every failed attempt, capped at an hour
+func retryDelay(failures int) time.Duration {
+	delay := RetryDelay
+	for i := 1; i < failures && delay < time.Hour; i++ {
+		delay *= 2
+	}
+	if delay > time.Hour {
+		delay = time.Hour
+	}
+	return delay
+}
+
+// Sign computes the hex HMAC-SHA256 of "<timestamp>.<body>"
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
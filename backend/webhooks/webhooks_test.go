@@ -0,0 +1,178 @@
+package webhooks
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rayzox/tickr-backend/database"
+	"github.com/rayzox/tickr-backend/models"
+)
+
+func testDB(t *testing.T) {
+	t.Helper()
+	previous := database.DB
+	database.ConnectDatabase(filepath.Join(t.TempDir(), "tickr.db"))
+	if err := database.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		database.Close()
+		database.DB = previous
+	})
+}
+
+func TestDelivery(t *testing.T) {
+	testDB(t)
+
+	type received struct {
+		header http.Header
+		body   []byte
+	}
+	var mu sync.Mutex
+	var requests []received
+	status := http.StatusInternalServerError
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, received{r.Header.Clone(), body})
+		code := status
+		mu.Unlock()
+		w.WriteHeader(code)
+	}))
+	defer server.Close()
+
+	hook := models.Webhook{URL: server.URL, Events: []string{"task.completed"}, Secret: "s3cret", Active: true}
+	if err := database.DB.Create(&hook).Error; err != nil {
+		t.Fatal(err)
+	}
+	Emit("habit.checked", nil) // not subscribed
+	Emit("task.completed", map[string]interface{}{"id": 1})
+
+	load := func() models.WebhookDelivery {
+		t.Helper()
+		var delivery models.WebhookDelivery
+		if err := database.DB.First(&delivery).Error; err != nil {
+			t.Fatal(err)
+		}
+		return delivery
+	}
+	makeDue := func() {
+		database.DB.Model(&models.WebhookDelivery{}).Where("status = ?", "pending").Update("next_attempt_at", time.Now())
+	}
+
+	// First attempt fails and is retried after RetryDelay
+	started := time.Now()
+	deliverDue(context.Background())
+	delivery := load()
+	if len(requests) != 1 {
+		t.Fatalf("%d requests, want 1", len(requests))
+	}
+	if delivery.Status != "pending" || len(delivery.Attempts) != 1 || delivery.Attempts[0].StatusCode != 500 {
+		t.Fatalf("after a 500: status %s, attempts %+v", delivery.Status, delivery.Attempts)
+	}
+	if wait := delivery.NextAttemptAt.Sub(started); wait < RetryDelay || wait > RetryDelay+5*time.Second {
+		t.Errorf("retried after %v, want %v", wait, RetryDelay)
+	}
+
+	// The signature covers the timestamp and the exact body
+	header, body := requests[0].header, requests[0].body
+	if got, want := header.Get("X-Tickr-Signature"), "sha256="+Sign("s3cret", header.Get("X-Tickr-Timestamp"), body); got != want {
+		t.Errorf("signature = %s, want %s", got, want)
+	}
+	if header.Get("X-Tickr-Event") != "task.completed" || string(body) != delivery.Payload {
+		t.Errorf("event %s, body %s", header.Get("X-Tickr-Event"), body)
+	}
+
+	// Not due yet, nothing is sent
+	deliverDue(context.Background())
+	if len(requests) != 1 {
+		t.Fatalf("%d requests before the retry was due, want 1", len(requests))
+	}
+
+	mu.Lock()
+	status = http.StatusNoContent
+	mu.Unlock()
+	makeDue()
+	deliverDue(context.Background())
+	if delivery = load(); delivery.Status != "succeeded" || len(delivery.Attempts) != 2 || delivery.NextAttemptAt != nil {
+		t.Errorf("after a 204: status %s, %d attempts, next %v", delivery.Status, len(delivery.Attempts), delivery.NextAttemptAt)
+	}
+}
+
+func TestDeliveryGivesUp(t *testing.T) {
+	testDB(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	hook := models.Webhook{URL: server.URL, Secret: "s3cret", Active: true}
+	database.DB.Create(&hook)
+	Emit("task.completed", nil)
+
+	for i := 0; i < MaxAttempts+2; i++ {
+		database.DB.Model(&models.WebhookDelivery{}).Where("status = ?", "pending").Update("next_attempt_at", time.Now())
+		deliverDue(context.Background())
+	}
+
+	var delivery models.WebhookDelivery
+	database.DB.First(&delivery)
+	if delivery.Status != "failed" || len(delivery.Attempts) != MaxAttempts {
+		t.Errorf("status %s after %d attempts, want failed after %d", delivery.Status, len(delivery.Attempts), MaxAttempts)
+	}
+}
+
+func TestDeliveryConcurrency(t *testing.T) {
+	testDB(t)
+
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer slow.Close()
+	defer close(release)
+	delivered := make(chan struct{}, 1)
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		delivered <- struct{}{}
+	}))
+	defer fast.Close()
+
+	database.DB.Create(&models.Webhook{URL: slow.URL, Secret: "a", Active: true})
+	database.DB.Create(&models.Webhook{URL: fast.URL, Secret: "b", Active: true})
+	Emit("task.completed", nil)
+
+	done := make(chan struct{})
+	go func() {
+		deliverDue(context.Background())
+		close(done)
+	}()
+
+	select {
+	case <-delivered:
+	case <-time.After(5 * time.Second):
+		t.Fatal("a slow webhook held up the others")
+	}
+	release <- struct{}{}
+	<-done
+}
+
+func TestRetryDelay(t *testing.T) {
+	for failures, want := range map[int]time.Duration{
+		1:  30 * time.Second,
+		2:  time.Minute,
+		3:  2 * time.Minute,
+		7:  32 * time.Minute,
+		8:  time.Hour,
+		20: time.Hour,
+	} {
+		if got := retryDelay(failures); got != want {
+			t.Errorf("retryDelay(%d) = %v, want %v", failures, got, want)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+import axios from 'axios'
+
+// The backend serves the API under /api/v1 on the same origin as the app, in
+// development the Vite dev server proxies it (see vite.config.js)
+const api = axios.create({ baseURL: '/api/v1' })
+
+// Responses come as { data, message, meta }, errors as { error: { code, message } }.
+// Components get the data, lists with all their pages.
+api.interceptors.response.use(async (response) => {
+  const { data, meta } = response.data ?? {}
+  if (meta && meta.page < meta.total_pages) {
+    const next = await api.request({
+      ...response.config,
+      params: { ...response.config.params, page: meta.page + 1 },
+    })
+    return { ...response, data: data.concat(next.data) }
+  }
+  return { ...response, data }
+})
+
+export default api
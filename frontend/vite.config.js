@@ -1,3 +1,4 @@
+import { writeFileSync } from 'node:fs'
 import { fileURLToPath, URL } from 'node:url'
 
 import { defineConfig } from 'vite'
@@ -5,6 +6,18 @@ import vue from '@vitejs/plugin-vue'
 import vueDevTools from 'vite-plugin-vue-devtools'
 import tailwindcss from '@tailwindcss/vite'
 
+// The build goes into the Go binary, which embeds backend/web/dist
+const outDir = fileURLToPath(new URL('../backend/web/dist', import.meta.url))
+
+// The embed needs the directory to exist even before the first build, put
+// back the placeholder emptyOutDir removes
+const keepPlaceholder = {
+  name: 'keep-placeholder',
+  apply: 'build',
+  closeBundle() {
+    writeFileSync(`${outDir}/.gitkeep`, '')
+  },
+}
 
 // https://vite.dev/config/
 export default defineConfig({
@@ -12,10 +25,21 @@ export default defineConfig({
     vue(),
     vueDevTools(),
     tailwindcss(),
+    keepPlaceholder,
   ],
   resolve: {
     alias: {
       '@': fileURLToPath(new URL('./src', import.meta.url))
     },
   },
+  build: {
+    outDir,
+    emptyOutDir: true,
+  },
+  server: {
+    // `go run .` in backend/, the app calls /api/v1 on its own origin
+    proxy: {
+      '/api': 'http://localhost:8080',
+    },
+  },
 })